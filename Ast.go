@@ -0,0 +1,137 @@
+package searchquerylexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Node is an element of the tree produced by Parser. It is one of
+*BinaryExpr, *NotExpr, *Comparison, *Range, *FreeText, or *Group.
+*/
+type Node interface {
+	fmt.Stringer
+	node()
+}
+
+/*
+BinaryExpr joins two expressions with a connective. AND binds tighter
+than OR, so "a or b and c" has an OR at its root.
+*/
+type BinaryExpr struct {
+	Connective Connective
+	Token      *Token
+	Left       Node
+	Right      Node
+}
+
+/*
+NotExpr negates the condition or subquery following a NOT.
+*/
+type NotExpr struct {
+	Token *Token
+	Expr  Node
+}
+
+/*
+Comparison is a single field, comparator, and value triple, such as
+title = "test". Comparators taking a list, such as IN, leave Value nil
+and set Values instead.
+*/
+type Comparison struct {
+	Field      *Token
+	Comparator *Token
+	Operator   Operator
+	Value      *Token
+	Values     []*Token
+}
+
+/*
+Range compares a field against a lower and upper bound, written either
+as age BETWEEN 18 AND 30 or as age = [18 TO 30]. A nil bound is open
+ended, as in created = {2024-01-01 TO *}.
+*/
+type Range struct {
+	Field        *Token
+	Comparator   *Token
+	Lower        *Token
+	Upper        *Token
+	IncludeLower bool
+	IncludeUpper bool
+}
+
+/*
+FreeText is a value standing on its own, such as invoice, which is not
+compared against any field. Compilers search Config.DefaultFields for it.
+*/
+type FreeText struct {
+	Value *Token
+}
+
+/*
+Group is an expression wrapped in a subquery.
+*/
+type Group struct {
+	Expr Node
+}
+
+func (*BinaryExpr) node() {}
+func (*NotExpr) node()    {}
+func (*Comparison) node() {}
+func (*Range) node()      {}
+func (*FreeText) node()   {}
+func (*Group) node()      {}
+
+func (n *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", n.Left.String(), n.Connective, n.Right.String())
+}
+
+func (n *NotExpr) String() string {
+	return fmt.Sprintf("not(%s)", n.Expr.String())
+}
+
+func (n *Comparison) String() string {
+	if n.Value == nil {
+		values := make([]string, 0, len(n.Values))
+
+		for _, value := range n.Values {
+			values = append(values, strconv.Quote(value.Value))
+		}
+
+		return fmt.Sprintf("%s %s [%s]", n.Field.Value, n.Operator, strings.Join(values, ", "))
+	}
+
+	return fmt.Sprintf("%s %s %s", n.Field.Value, n.Operator, strconv.Quote(n.Value.Value))
+}
+
+func (n *Range) String() string {
+	lower, upper := RangeOpenBound, RangeOpenBound
+	open, end := "{", "}"
+
+	if n.Lower != nil {
+		lower = strconv.Quote(n.Lower.Value)
+	}
+
+	if n.Upper != nil {
+		upper = strconv.Quote(n.Upper.Value)
+	}
+
+	if n.IncludeLower {
+		open = "["
+	}
+
+	if n.IncludeUpper {
+		end = "]"
+	}
+
+	return fmt.Sprintf("%s between %s%s, %s%s", n.Field.Value, open, lower, upper, end)
+}
+
+func (n *FreeText) String() string {
+	return fmt.Sprintf("text(%s)", strconv.Quote(n.Value.Value))
+}
+
+func (n *Group) String() string {
+	return fmt.Sprintf("group(%s)", n.Expr.String())
+}
@@ -2,71 +2,413 @@ package searchquerylexer
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"unicode"
 )
 
+/*
+Config describes the search dialect. When ImplicitConnective is set to
+ConnectiveAnd or ConnectiveOr, adjacent conditions with nothing between
+them, such as title="foo" age>3, are joined by that connective.
+
+DefaultFields names the string fields searched by free text terms, which
+are values not compared against a field, such as invoice. Compilers
+expand a free text term into an OR across these fields.
+*/
 type Config struct {
-	ComparatorConfig ComparatorConfig
-	ConnectiveConfig ConnectiveConfig
-	FieldNames       []string
+	ComparatorConfig   ComparatorConfig `json:"comparators" yaml:"comparators"`
+	ConnectiveConfig   ConnectiveConfig `json:"connectives" yaml:"connectives"`
+	QuoteConfig        QuoteConfig      `json:"quoting" yaml:"quoting"`
+	FieldNames         []string         `json:"fieldNames,omitempty" yaml:"fieldNames,omitempty"`
+	Fields             []FieldConfig    `json:"fields,omitempty" yaml:"fields,omitempty"`
+	ImplicitConnective Connective       `json:"implicitConnective,omitempty" yaml:"implicitConnective,omitempty"`
+	DefaultFields      []string         `json:"defaultFields,omitempty" yaml:"defaultFields,omitempty"`
+}
+
+/*
+FieldConfig describes a searchable field in more detail than FieldNames
+allows. Name is what users type. Column is the column, expression, or
+dotted document path a compiler should use in its place, and defaults
+to Name. Comparators restricts which comparators may be used with the
+field. When empty, all comparators are allowed.
+
+Type controls how values compared against the field are validated and
+converted, and defaults to FieldTypeString. Values lists the allowed
+values of a FieldTypeEnum field.
+*/
+type FieldConfig struct {
+	Name        string     `json:"name" yaml:"name"`
+	Column      string     `json:"column,omitempty" yaml:"column,omitempty"`
+	Comparators []Operator `json:"comparators,omitempty" yaml:"comparators,omitempty"`
+	Type        FieldType  `json:"type,omitempty" yaml:"type,omitempty"`
+	Values      []string   `json:"values,omitempty" yaml:"values,omitempty"`
 }
 
+/*
+ComparatorConfig holds the comparators. In and NotIn compare a field
+against a list of values, such as category IN ("a", "b"). Between
+compares a field against an inclusive range, such as
+age BETWEEN 18 AND 30. Each of these may be left empty to disable it.
+*/
 type ComparatorConfig struct {
-	Equal              string
-	NotEqual           string
-	LessThan           string
-	GreaterThan        string
-	LessThanEqualTo    string
-	GreaterThanEqualTo string
-	Like               string
-	NotLike            string
+	Equal              string `json:"equal" yaml:"equal"`
+	NotEqual           string `json:"notEqual" yaml:"notEqual"`
+	LessThan           string `json:"lessThan" yaml:"lessThan"`
+	GreaterThan        string `json:"greaterThan" yaml:"greaterThan"`
+	LessThanEqualTo    string `json:"lessThanEqualTo" yaml:"lessThanEqualTo"`
+	GreaterThanEqualTo string `json:"greaterThanEqualTo" yaml:"greaterThanEqualTo"`
+	Like               string `json:"like" yaml:"like"`
+	NotLike            string `json:"notLike" yaml:"notLike"`
+	In                 string `json:"in" yaml:"in"`
+	NotIn              string `json:"notIn" yaml:"notIn"`
+	Between            string `json:"between" yaml:"between"`
 }
 
+/*
+ConnectiveConfig holds the boolean operators. Not is the word used to
+negate the condition or subquery following it, such as
+NOT (category = "bad"). NotPrefix is an optional symbol doing the same
+when written directly in front of a field name or subquery, such as
+-title:draft. Either may be left empty to disable it.
+*/
 type ConnectiveConfig struct {
-	And string
-	Or  string
+	And       string `json:"and" yaml:"and"`
+	Or        string `json:"or" yaml:"or"`
+	Not       string `json:"not" yaml:"not"`
+	NotPrefix string `json:"notPrefix" yaml:"notPrefix"`
 }
 
+/*
+QuoteConfig holds the characters that may quote a value. Each character
+in Quotes starts a string ending at the same character, in which
+backslash escapes such as \" and \n are recognized. Each character in
+Raw does the same, without any escapes. When both are empty, values are
+quoted with " only.
+*/
+type QuoteConfig struct {
+	Quotes string `json:"quotes" yaml:"quotes"`
+	Raw    string `json:"raw" yaml:"raw"`
+}
+
+/*
+validate reports the first problem with the config as a *ConfigError,
+naming the offending key the way it is spelled in JSON and YAML.
+*/
 func (c Config) validate() error {
-	if strings.TrimSpace(c.ComparatorConfig.Equal) == "" {
-		return fmt.Errorf("missing EQUAL configuration: %w", ErrInvalidConfigComparator)
+	comparators := []struct {
+		path  string
+		value string
+		name  string
+	}{
+		{"comparators.equal", c.ComparatorConfig.Equal, "EQUAL"},
+		{"comparators.notEqual", c.ComparatorConfig.NotEqual, "NOT EQUAL"},
+		{"comparators.lessThan", c.ComparatorConfig.LessThan, "LESS THAN"},
+		{"comparators.greaterThan", c.ComparatorConfig.GreaterThan, "GREATER THAN"},
+		{"comparators.lessThanEqualTo", c.ComparatorConfig.LessThanEqualTo, "LESS THAN EQUAL"},
+		{"comparators.greaterThanEqualTo", c.ComparatorConfig.GreaterThanEqualTo, "GREATER THAN EQUAL"},
+		{"comparators.like", c.ComparatorConfig.Like, "LIKE"},
+		{"comparators.notLike", c.ComparatorConfig.NotLike, "NOT LIKE"},
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.NotEqual) == "" {
-		return fmt.Errorf("missing NOT EQUAL configuration: %w", ErrInvalidConfigComparator)
+	for _, comparator := range comparators {
+		if strings.TrimSpace(comparator.value) == "" {
+			return configError(comparator.path, fmt.Errorf("missing %s configuration: %w", comparator.name, ErrInvalidConfigComparator))
+		}
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.LessThan) == "" {
-		return fmt.Errorf("missing LESS THAN configuration: %w", ErrInvalidConfigComparator)
+	seenComparators := map[string]string{}
+
+	for _, configured := range c.ComparatorConfig.operators() {
+		if configured.comparator == "" {
+			continue
+		}
+
+		folded := strings.ToLower(configured.comparator)
+
+		if other, ok := seenComparators[folded]; ok {
+			return configError("comparators."+configured.key, fmt.Errorf("comparator '%s' is already used by comparators.%s: %w", configured.comparator, other, ErrInvalidConfigComparator))
+		}
+
+		seenComparators[folded] = configured.key
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.GreaterThan) == "" {
-		return fmt.Errorf("missing GREATER THAN configuration: %w", ErrInvalidConfigComparator)
+	if strings.TrimSpace(c.ConnectiveConfig.And) == "" {
+		return configError("connectives.and", fmt.Errorf("missing AND configuration: %w", ErrInvalidConfigConnective))
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.LessThanEqualTo) == "" {
-		return fmt.Errorf("missing LESS THAN EQUAL configuration: %w", ErrInvalidConfigComparator)
+	if strings.TrimSpace(c.ConnectiveConfig.Or) == "" {
+		return configError("connectives.or", fmt.Errorf("missing OR configuration: %w", ErrInvalidConfigConnective))
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.GreaterThanEqualTo) == "" {
-		return fmt.Errorf("missing GREATER THAN EQUAL configuration: %w", ErrInvalidConfigComparator)
+	if c.ImplicitConnective != "" && c.ImplicitConnective != ConnectiveAnd && c.ImplicitConnective != ConnectiveOr {
+		return configError("implicitConnective", fmt.Errorf("implicit connective must be '%s' or '%s': %w", ConnectiveAnd, ConnectiveOr, ErrInvalidConfigConnective))
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.Like) == "" {
-		return fmt.Errorf("missing LIKE configuration: %w", ErrInvalidConfigComparator)
+	not := c.ConnectiveConfig.Not
+
+	if not != "" && (strings.EqualFold(not, c.ConnectiveConfig.And) || strings.EqualFold(not, c.ConnectiveConfig.Or)) {
+		return configError("connectives.not", fmt.Errorf("NOT configuration '%s' is already used by AND or OR: %w", not, ErrInvalidConfigConnective))
 	}
 
-	if strings.TrimSpace(c.ComparatorConfig.NotLike) == "" {
-		return fmt.Errorf("missing NOT LIKE configuration: %w", ErrInvalidConfigComparator)
+	if err := c.QuoteConfig.validate(); err != nil {
+		return err
 	}
 
-	if strings.TrimSpace(c.ConnectiveConfig.And) == "" {
-		return fmt.Errorf("missing AND configuration: %w", ErrInvalidConfigConnective)
+	seen := map[string]bool{}
+
+	for i, field := range c.allFields() {
+		path := fmt.Sprintf("fieldNames[%d]", i)
+
+		if i >= len(c.FieldNames) {
+			path = fmt.Sprintf("fields[%d]", i-len(c.FieldNames))
+		}
+
+		if err := field.validate(path, seen); err != nil {
+			return err
+		}
 	}
 
-	if strings.TrimSpace(c.ConnectiveConfig.Or) == "" {
-		return fmt.Errorf("missing OR configuration: %w", ErrInvalidConfigConnective)
+	for i, name := range c.DefaultFields {
+		path := fmt.Sprintf("defaultFields[%d]", i)
+		field, ok := c.Field(name)
+
+		if !ok {
+			return configError(path, fmt.Errorf("default field '%s' is not a configured field: %w", name, ErrInvalidConfigField))
+		}
+
+		if field.Type != "" && field.Type != FieldTypeString {
+			return configError(path, fmt.Errorf("default field '%s' must be a string field: %w", name, ErrInvalidConfigField))
+		}
 	}
 
 	return nil
 }
+
+/*
+validate checks a single field found at path. seen holds the lower
+cased names of the fields before it.
+*/
+func (f FieldConfig) validate(path string, seen map[string]bool) error {
+	namePath := path
+
+	// Entries of fieldNames are only a name
+	if strings.HasPrefix(path, "fields[") {
+		namePath = path + ".name"
+	}
+
+	if strings.TrimSpace(f.Name) == "" {
+		return configError(namePath, fmt.Errorf("missing field name: %w", ErrInvalidConfigField))
+	}
+
+	if seen[strings.ToLower(f.Name)] {
+		return configError(namePath, fmt.Errorf("field '%s' is configured more than once: %w", f.Name, ErrInvalidConfigField))
+	}
+
+	seen[strings.ToLower(f.Name)] = true
+
+	if !f.Type.valid() {
+		return configError(path+".type", fmt.Errorf("field '%s' has unknown type '%s': %w", f.Name, f.Type, ErrInvalidConfigField))
+	}
+
+	if f.Type == FieldTypeEnum && len(f.Values) == 0 {
+		return configError(path+".values", fmt.Errorf("enum field '%s' has no values: %w", f.Name, ErrInvalidConfigField))
+	}
+
+	if f.Type != FieldTypeEnum && len(f.Values) > 0 {
+		return configError(path+".values", fmt.Errorf("field '%s' has values but is not an enum: %w", f.Name, ErrInvalidConfigField))
+	}
+
+	for i, operator := range f.Comparators {
+		if !operator.valid() {
+			return configError(fmt.Sprintf("%s.comparators[%d]", path, i), fmt.Errorf("field '%s' has unknown comparator '%s': %w", f.Name, operator, ErrInvalidConfigField))
+		}
+	}
+
+	return nil
+}
+
+func (c QuoteConfig) validate() error {
+	seen := map[rune]bool{}
+
+	for _, quotes := range []struct{ path, value string }{{"quoting.quotes", c.Quotes}, {"quoting.raw", c.Raw}} {
+		for _, quote := range quotes.value {
+			if seen[quote] {
+				return configError(quotes.path, fmt.Errorf("quote '%c' is configured more than once: %w", quote, ErrInvalidConfigQuote))
+			}
+
+			seen[quote] = true
+
+			if unicode.IsSpace(quote) || isWordChar(quote) || strings.ContainsRune(`\()[]{},/`+string(WildcardAny)+string(WildcardOne), quote) {
+				return configError(quotes.path, fmt.Errorf("'%c' cannot be used as a quote: %w", quote, ErrInvalidConfigQuote))
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+withDefaults returns the quote configuration with " as the only quote
+when none are configured.
+*/
+func (c QuoteConfig) withDefaults() QuoteConfig {
+	if c.Quotes == "" && c.Raw == "" {
+		c.Quotes = `"`
+	}
+
+	return c
+}
+
+/*
+Field returns the configuration for the field users refer to as name.
+Fields listed in FieldNames are returned with only their Name set.
+*/
+func (c Config) Field(name string) (FieldConfig, bool) {
+	for _, field := range c.allFields() {
+		if strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+
+	return FieldConfig{}, false
+}
+
+/*
+Column returns the column configured for the field users refer to as
+name. Unlike Field, it fails with ErrUnknownField for names that are not
+configured, so a field token that did not come from the lexer is never
+written into a query as it is.
+*/
+func (c Config) Column(name string) (string, error) {
+	field, ok := c.Field(name)
+
+	if !ok {
+		return "", fmt.Errorf("'%s': %w", name, ErrUnknownField)
+	}
+
+	return field.ColumnName(), nil
+}
+
+/*
+FreeTextFields returns the configuration of each of the DefaultFields,
+in the order they are listed.
+*/
+func (c Config) FreeTextFields() []FieldConfig {
+	result := make([]FieldConfig, 0, len(c.DefaultFields))
+
+	for _, name := range c.DefaultFields {
+		if field, ok := c.Field(name); ok {
+			result = append(result, field)
+		}
+	}
+
+	return result
+}
+
+func (c Config) allFields() []FieldConfig {
+	result := make([]FieldConfig, 0, len(c.FieldNames)+len(c.Fields))
+
+	for _, name := range c.FieldNames {
+		result = append(result, FieldConfig{Name: name})
+	}
+
+	return append(result, c.Fields...)
+}
+
+/*
+ColumnName returns the column or expression backing the field, which
+is the field name unless Column is set.
+*/
+func (f FieldConfig) ColumnName() string {
+	if f.Column != "" {
+		return f.Column
+	}
+
+	return f.Name
+}
+
+func (f FieldConfig) allows(operator Operator) bool {
+	if len(f.Comparators) == 0 {
+		return true
+	}
+
+	return slices.Contains(f.Comparators, operator)
+}
+
+/*
+configuredComparator is one entry of ComparatorConfig. Key is its name
+in JSON and YAML.
+*/
+type configuredComparator struct {
+	operator   Operator
+	comparator string
+	key        string
+}
+
+/*
+operators lists every comparator in a fixed order, including those that
+are disabled.
+*/
+func (c ComparatorConfig) operators() []configuredComparator {
+	return []configuredComparator{
+		{OperatorEqual, c.Equal, "equal"},
+		{OperatorNotEqual, c.NotEqual, "notEqual"},
+		{OperatorLessThan, c.LessThan, "lessThan"},
+		{OperatorGreaterThan, c.GreaterThan, "greaterThan"},
+		{OperatorLessThanEqualTo, c.LessThanEqualTo, "lessThanEqualTo"},
+		{OperatorGreaterThanEqualTo, c.GreaterThanEqualTo, "greaterThanEqualTo"},
+		{OperatorLike, c.Like, "like"},
+		{OperatorNotLike, c.NotLike, "notLike"},
+		{OperatorIn, c.In, "in"},
+		{OperatorNotIn, c.NotIn, "notIn"},
+		{OperatorBetween, c.Between, "between"},
+	}
+}
+
+func (c ComparatorConfig) operator(comparator string) (Operator, bool) {
+	for _, configured := range c.operators() {
+		if configured.comparator != "" && strings.EqualFold(configured.comparator, comparator) {
+			return configured.operator, true
+		}
+	}
+
+	return "", false
+}
+
+/*
+comparator returns the comparator configured for operator, which is
+empty when the operator is disabled.
+*/
+func (c ComparatorConfig) comparator(operator Operator) string {
+	for _, configured := range c.operators() {
+		if configured.operator == operator {
+			return configured.comparator
+		}
+	}
+
+	return ""
+}
+
+/*
+word returns the configured word for connective.
+*/
+func (c ConnectiveConfig) word(connective Connective) string {
+	if connective == ConnectiveOr {
+		return c.Or
+	}
+
+	return c.And
+}
+
+func (c ConnectiveConfig) connective(connective string) (Connective, bool) {
+	if strings.EqualFold(c.And, connective) {
+		return ConnectiveAnd, true
+	}
+
+	if strings.EqualFold(c.Or, connective) {
+		return ConnectiveOr, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,24 @@
+package searchquerylexer
+
+/*
+ConfigError describes a problem with a Config. Path is the offending
+key as it is spelled in JSON and YAML, such as fields[2].type. Err is
+the underlying cause, so errors.Is works against sentinels such as
+ErrInvalidConfigField.
+*/
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func configError(path string, err error) error {
+	return &ConfigError{Path: path, Err: err}
+}
@@ -0,0 +1,188 @@
+package searchquerylexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ConfigFromJSON decodes a Config from JSON and validates it. Keys are
+spelled as in the json tags of Config, such as comparators.equal or
+fields[0].type. Comparators and connectives left out keep the values of
+DefaultComparatorConfig and DefaultConnectiveConfig, so a file only has
+to hold what it changes. Unknown keys are reported as a *ConfigError
+naming the key, so that a misspelled setting does not go unnoticed.
+Data that is not valid JSON, or holds a value of the wrong type, is
+reported as ErrInvalidConfigFile.
+*/
+func ConfigFromJSON(data []byte) (Config, error) {
+	var (
+		keys any
+	)
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", err.Error(), ErrInvalidConfigFile)
+	}
+
+	// encoding/json matches keys to fields ignoring case
+	if err := checkKeys(keys, reflect.TypeFor[Config](), "", "json", strings.EqualFold); err != nil {
+		return Config{}, err
+	}
+
+	result := defaultFileConfig()
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", err.Error(), ErrInvalidConfigFile)
+	}
+
+	if err := result.validate(); err != nil {
+		return Config{}, err
+	}
+
+	return result, nil
+}
+
+/*
+ConfigFromYAML is the same as ConfigFromJSON, for YAML. An empty
+document gives the default config.
+*/
+func ConfigFromYAML(data []byte) (Config, error) {
+	var (
+		keys any
+	)
+
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", err.Error(), ErrInvalidConfigFile)
+	}
+
+	equal := func(a, b string) bool { return a == b }
+
+	if err := checkKeys(keys, reflect.TypeFor[Config](), "", "yaml", equal); err != nil {
+		return Config{}, err
+	}
+
+	result := defaultFileConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&result); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("%s: %w", err.Error(), ErrInvalidConfigFile)
+	}
+
+	if err := result.validate(); err != nil {
+		return Config{}, err
+	}
+
+	return result, nil
+}
+
+/*
+checkKeys walks a decoded document alongside the type it will be
+decoded into, returning a *ConfigError for the first key, in sorted
+order, that t has no field for. Paths are written the same way as
+validate writes them. Values of the wrong shape are skipped, as the
+decoder reports those.
+*/
+func checkKeys(value any, t reflect.Type, path, tag string, match func(a, b string) bool) error {
+	switch t.Kind() {
+	case reflect.Struct:
+		document, ok := value.(map[string]any)
+
+		if !ok {
+			return nil
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(document)) {
+			keyPath := key
+
+			if path != "" {
+				keyPath = path + "." + key
+			}
+
+			field, ok := structFieldByTag(t, key, tag, match)
+
+			if !ok {
+				return configError(keyPath, fmt.Errorf("unknown key '%s': %w", key, ErrInvalidConfigFile))
+			}
+
+			if err := checkKeys(document[key], field.Type, keyPath, tag, match); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		items, ok := value.([]any)
+
+		if !ok {
+			return nil
+		}
+
+		for i, item := range items {
+			if err := checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), tag, match); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func structFieldByTag(t reflect.Type, key, tag string, match func(a, b string) bool) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+
+		if name != "" && name != "-" && match(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+/*
+LoadConfig reads a Config from a file, decoding it as JSON or YAML
+depending on whether its extension is .json, or .yaml or .yml.
+*/
+func LoadConfig(path string) (Config, error) {
+	var (
+		decode func(data []byte) (Config, error)
+	)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decode = ConfigFromJSON
+
+	case ".yaml", ".yml":
+		decode = ConfigFromYAML
+
+	default:
+		return Config{}, fmt.Errorf("'%s' is not a .json, .yaml, or .yml file: %w", path, ErrInvalidConfigFile)
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return Config{}, err
+	}
+
+	return decode(data)
+}
+
+func defaultFileConfig() Config {
+	return Config{
+		ComparatorConfig: DefaultComparatorConfig,
+		ConnectiveConfig: DefaultConnectiveConfig,
+	}
+}
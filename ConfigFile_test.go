@@ -0,0 +1,264 @@
+package searchquerylexer_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func fileConfig() sql.Config {
+	comparators := sql.DefaultComparatorConfig
+	comparators.Like = "~"
+	comparators.NotLike = "!~"
+	comparators.In = ""
+	comparators.NotIn = ""
+
+	connectives := sql.DefaultConnectiveConfig
+	connectives.NotPrefix = "-"
+
+	return sql.Config{
+		ComparatorConfig: comparators,
+		ConnectiveConfig: connectives,
+		QuoteConfig:      sql.QuoteConfig{Quotes: `"'`},
+		FieldNames:       []string{"title"},
+		Fields: []sql.FieldConfig{
+			{
+				Name:        "age",
+				Type:        sql.FieldTypeInt,
+				Comparators: []sql.Operator{sql.OperatorEqual, sql.OperatorLessThan, sql.OperatorGreaterThan},
+			},
+			{
+				Name:   "status",
+				Column: "documents.status",
+				Type:   sql.FieldTypeEnum,
+				Values: []string{"draft", "published"},
+			},
+		},
+		ImplicitConnective: sql.ConnectiveAnd,
+		DefaultFields:      []string{"title"},
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, path := range []string{"testdata/config.json", "testdata/config.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			config, err := sql.LoadConfig(path)
+
+			assert.NoError(t, err)
+			assert.Equal(t, fileConfig(), config)
+		})
+	}
+
+	t.Run("unknown extension", func(t *testing.T) {
+		_, err := sql.LoadConfig("testdata/config.toml")
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigFile)
+	})
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	want := fileConfig()
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(want)
+		assert.NoError(t, err)
+
+		got, err := sql.ConfigFromJSON(data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		data, err := yaml.Marshal(want)
+		assert.NoError(t, err)
+
+		got, err := sql.ConfigFromYAML(data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestConfigFromYAMLDefaults(t *testing.T) {
+	config, err := sql.ConfigFromYAML([]byte(""))
+
+	assert.NoError(t, err)
+	assert.Equal(t, sql.DefaultComparatorConfig, config.ComparatorConfig)
+	assert.Equal(t, sql.DefaultConnectiveConfig, config.ConnectiveConfig)
+}
+
+func TestConfigErrors(t *testing.T) {
+	table := []struct {
+		name        string
+		json        string
+		yaml        string
+		path        string
+		expectedErr error
+	}{
+		{
+			name:        "missing comparator",
+			json:        `{"comparators": {"equal": " "}}`,
+			yaml:        "comparators:\n  equal: ' '\n",
+			path:        "comparators.equal",
+			expectedErr: sql.ErrInvalidConfigComparator,
+		},
+		{
+			name:        "missing connective",
+			json:        `{"connectives": {"or": ""}}`,
+			yaml:        "connectives:\n  or: ''\n",
+			path:        "connectives.or",
+			expectedErr: sql.ErrInvalidConfigConnective,
+		},
+		{
+			name:        "implicit connective",
+			json:        `{"implicitConnective": "xor"}`,
+			yaml:        "implicitConnective: xor\n",
+			path:        "implicitConnective",
+			expectedErr: sql.ErrInvalidConfigConnective,
+		},
+		{
+			name:        "raw quote",
+			json:        `{"quoting": {"quotes": "\"", "raw": "("}}`,
+			yaml:        "quoting:\n  quotes: '\"'\n  raw: '('\n",
+			path:        "quoting.raw",
+			expectedErr: sql.ErrInvalidConfigQuote,
+		},
+		{
+			name:        "duplicate field name",
+			json:        `{"fieldNames": ["title"], "fields": [{"name": "age"}, {"name": "Title"}]}`,
+			yaml:        "fieldNames: [title]\nfields:\n  - name: age\n  - name: Title\n",
+			path:        "fields[1].name",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+		{
+			name:        "empty field name",
+			json:        `{"fieldNames": ["title", ""]}`,
+			yaml:        "fieldNames: [title, '']\n",
+			path:        "fieldNames[1]",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+		{
+			name:        "field type",
+			json:        `{"fields": [{"name": "age", "type": "number"}]}`,
+			yaml:        "fields:\n  - name: age\n    type: number\n",
+			path:        "fields[0].type",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+		{
+			name:        "enum values",
+			json:        `{"fields": [{"name": "status", "type": "enum"}]}`,
+			yaml:        "fields:\n  - name: status\n    type: enum\n",
+			path:        "fields[0].values",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+		{
+			name:        "field comparator",
+			json:        `{"fields": [{"name": "age", "comparators": ["eq", "contains"]}]}`,
+			yaml:        "fields:\n  - name: age\n    comparators: [eq, contains]\n",
+			path:        "fields[0].comparators[1]",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+		{
+			name:        "default field",
+			json:        `{"fieldNames": ["title"], "defaultFields": ["title", "body"]}`,
+			yaml:        "fieldNames: [title]\ndefaultFields: [title, body]\n",
+			path:        "defaultFields[1]",
+			expectedErr: sql.ErrInvalidConfigField,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				configErr *sql.ConfigError
+			)
+
+			_, err := sql.ConfigFromJSON([]byte(tt.json))
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.True(t, errors.As(err, &configErr))
+			assert.Equal(t, tt.path, configErr.Path)
+
+			_, err = sql.ConfigFromYAML([]byte(tt.yaml))
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.True(t, errors.As(err, &configErr))
+			assert.Equal(t, tt.path, configErr.Path)
+		})
+	}
+}
+
+func TestConfigDecodeErrors(t *testing.T) {
+	t.Run("unknown keys are reported with their path", func(t *testing.T) {
+		table := []struct {
+			json string
+			yaml string
+			path string
+		}{
+			{
+				json: `{"comparators": {"equals": "="}}`,
+				yaml: "comparators:\n  equals: '='\n",
+				path: "comparators.equals",
+			},
+			{
+				json: `{"fields": [{"name": "age"}, {"name": "title", "colum": "t"}]}`,
+				yaml: "fields:\n  - name: age\n  - name: title\n    colum: t\n",
+				path: "fields[1].colum",
+			},
+			{
+				json: `{"sortable": true}`,
+				yaml: "sortable: true\n",
+				path: "sortable",
+			},
+		}
+
+		for _, tt := range table {
+			var (
+				configErr *sql.ConfigError
+			)
+
+			_, err := sql.ConfigFromJSON([]byte(tt.json))
+
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigFile)
+			assert.True(t, errors.As(err, &configErr))
+			assert.Equal(t, tt.path, configErr.Path)
+
+			_, err = sql.ConfigFromYAML([]byte(tt.yaml))
+
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigFile)
+			assert.True(t, errors.As(err, &configErr))
+			assert.Equal(t, tt.path, configErr.Path)
+		}
+	})
+
+	t.Run("json keys ignore case, as encoding/json does", func(t *testing.T) {
+		config, err := sql.ConfigFromJSON([]byte(`{"FieldNames": ["title"]}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"title"}, config.FieldNames)
+	})
+
+	t.Run("documents that do not decode", func(t *testing.T) {
+		var (
+			configErr *sql.ConfigError
+		)
+
+		for _, data := range []string{`{`, `{"fieldNames": "title"}`} {
+			_, err := sql.ConfigFromJSON([]byte(data))
+
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigFile)
+			assert.False(t, errors.As(err, &configErr))
+		}
+
+		for _, data := range []string{"fieldNames: [", "fieldNames: title\n"} {
+			_, err := sql.ConfigFromYAML([]byte(data))
+
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigFile)
+			assert.False(t, errors.As(err, &configErr))
+		}
+	})
+}
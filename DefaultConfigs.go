@@ -15,3 +15,41 @@ var DefaultConnectiveConfig = ConnectiveConfig{
 	And: "and",
 	Or:  "or",
 }
+
+/*
+ExtendedComparatorConfig is DefaultComparatorConfig with the in, not in,
+and between comparators. They are left out of the defaults so that
+values such as in keep lexing as they always have.
+*/
+var ExtendedComparatorConfig = ComparatorConfig{
+	Equal:              "=",
+	NotEqual:           "!=",
+	LessThan:           "<",
+	GreaterThan:        ">",
+	LessThanEqualTo:    "<=",
+	GreaterThanEqualTo: ">=",
+	Like:               "=~",
+	NotLike:            "!~",
+	In:                 "in",
+	NotIn:              "not in",
+	Between:            "between",
+}
+
+/*
+ExtendedConnectiveConfig is DefaultConnectiveConfig with not for
+negation.
+*/
+var ExtendedConnectiveConfig = ConnectiveConfig{
+	And: "and",
+	Or:  "or",
+	Not: "not",
+}
+
+/*
+DefaultQuoteConfig allows values to be quoted with double or single
+quotes, or with backticks for raw strings without escapes.
+*/
+var DefaultQuoteConfig = QuoteConfig{
+	Quotes: `"'`,
+	Raw:    "`",
+}
@@ -5,7 +5,29 @@ import "errors"
 var (
 	ErrInvalidEscapeSequence error = errors.New("invalid escape sequence")
 	ErrInvalidConnective     error = errors.New("invalid connective")
+	ErrInvalidNegation       error = errors.New("invalid negation")
+	ErrComparatorNotAllowed  error = errors.New("comparator not allowed")
+	ErrInvalidValue          error = errors.New("invalid value")
+	ErrInvalidList           error = errors.New("invalid list")
+	ErrInvalidRange          error = errors.New("invalid range")
+	ErrInvalidRegex          error = errors.New("invalid regular expression")
+	ErrUnterminatedString    error = errors.New("unterminated string")
+	ErrUnbalancedParentheses error = errors.New("unbalanced parentheses")
+
+	ErrFieldNameAsValue   error = errors.New("field name used as value")
+	ErrDanglingComparator error = errors.New("dangling comparator")
+
+	ErrUnexpectedToken      error = errors.New("unexpected token")
+	ErrUnexpectedEndOfInput error = errors.New("unexpected end of input")
+
+	ErrUnknownField        error = errors.New("unknown field")
+	ErrUnsupportedNode     error = errors.New("unsupported node")
+	ErrUnsupportedOperator error = errors.New("unsupported operator")
+	ErrNoDefaultFields     error = errors.New("no default fields configured for free text search")
 
 	ErrInvalidConfigComparator error = errors.New("invalid comparator config")
 	ErrInvalidConfigConnective error = errors.New("invalid connective config")
+	ErrInvalidConfigField      error = errors.New("invalid field config")
+	ErrInvalidConfigQuote      error = errors.New("invalid quote config")
+	ErrInvalidConfigFile       error = errors.New("invalid config file")
 )
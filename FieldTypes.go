@@ -0,0 +1,101 @@
+package searchquerylexer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType string
+
+const (
+	FieldTypeString   FieldType = "string"
+	FieldTypeInt      FieldType = "int"
+	FieldTypeFloat    FieldType = "float"
+	FieldTypeBool     FieldType = "bool"
+	FieldTypeTime     FieldType = "time"
+	FieldTypeDuration FieldType = "duration"
+	FieldTypeEnum     FieldType = "enum"
+	FieldTypeUUID     FieldType = "uuid"
+)
+
+/*
+TimeLayouts are the layouts, tried in order, used to parse values of
+FieldTypeTime fields.
+*/
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (t FieldType) valid() bool {
+	switch t {
+	case "", FieldTypeString, FieldTypeInt, FieldTypeFloat, FieldTypeBool,
+		FieldTypeTime, FieldTypeDuration, FieldTypeEnum, FieldTypeUUID:
+		return true
+	}
+
+	return false
+}
+
+/*
+convert turns value into the Go type for the field. The results are
+string, int64, float64, bool, time.Time, time.Duration, and, for enums
+and UUIDs, the canonical string.
+*/
+func (f FieldConfig) convert(value string) (any, error) {
+	switch f.Type {
+	case "", FieldTypeString:
+		return value, nil
+
+	case FieldTypeInt:
+		if result, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return result, nil
+		}
+
+	case FieldTypeFloat:
+		if result, err := strconv.ParseFloat(value, 64); err == nil {
+			return result, nil
+		}
+
+	case FieldTypeBool:
+		if result, err := strconv.ParseBool(value); err == nil {
+			return result, nil
+		}
+
+	case FieldTypeTime:
+		for _, layout := range TimeLayouts {
+			if result, err := time.Parse(layout, value); err == nil {
+				return result, nil
+			}
+		}
+
+	case FieldTypeDuration:
+		if result, err := time.ParseDuration(value); err == nil {
+			return result, nil
+		}
+
+	case FieldTypeEnum:
+		for _, enumValue := range f.Values {
+			if strings.EqualFold(enumValue, value) {
+				return enumValue, nil
+			}
+		}
+
+		return nil, fmt.Errorf("value '%s' for field '%s' must be one of %s: %w", value, f.Name, strings.Join(f.Values, ", "), ErrInvalidValue)
+
+	case FieldTypeUUID:
+		if uuidPattern.MatchString(value) {
+			return strings.ToLower(value), nil
+		}
+	}
+
+	return nil, fmt.Errorf("value '%s' is not a valid %s for field '%s': %w", value, f.Type, f.Name, ErrInvalidValue)
+}
@@ -0,0 +1,420 @@
+package searchquerylexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Format writes tokens back out as a query, in a canonical form suitable
+for storing. Connectives, comparators, and negations are written the way
+config spells them, comparators and connectives are surrounded by single
+spaces, and values are quoted when they were quoted in the query or
+have to be. Quoting is therefore not minimal: category = '30' is written
+as category = "30", so the value keeps its Quoted flag. Implicit
+connectives are left out. Tokenizing the result with the same config
+gives back the same tokens.
+*/
+func Format(tokens []*Token, config Config) string {
+	f := &formatter{
+		config: config,
+		quotes: config.QuoteConfig.withDefaults(),
+	}
+
+	for _, token := range tokens {
+		f.write(token)
+	}
+
+	return f.result.String()
+}
+
+/*
+FormatNode writes a parsed tree back out as a query, in the same form
+as Format.
+*/
+func FormatNode(node Node, config Config) string {
+	return Format(nodeTokens(node, config), config)
+}
+
+type formatter struct {
+	config     Config
+	quotes     QuoteConfig
+	result     strings.Builder
+	prev       *Token
+	inList     bool
+	inBrackets bool
+}
+
+func (f *formatter) write(token *Token) {
+	if token.Type == TokenTypeConnective && token.Implicit {
+		return
+	}
+
+	text := f.text(token)
+
+	if f.prev != nil && f.spaceBetween(f.prev, token) {
+		f.result.WriteString(" ")
+	}
+
+	f.result.WriteString(text)
+
+	switch token.Type {
+	case TokenTypeListStart:
+		f.inList = true
+
+	case TokenTypeListEnd:
+		f.inList = false
+
+	case TokenTypeRangeStart:
+		f.inBrackets = true
+
+	case TokenTypeRangeEnd:
+		f.inBrackets = false
+	}
+
+	f.prev = token
+}
+
+func (f *formatter) spaceBetween(prev, token *Token) bool {
+	switch prev.Type {
+	case TokenTypeSubqueryStart, TokenTypeListStart, TokenTypeRangeStart:
+		return false
+
+	// Connectives are only scanned as such when followed by whitespace
+	case TokenTypeConnective:
+		return true
+
+	case TokenTypeNegation:
+		if f.isNotPrefix(prev) {
+			return false
+		}
+	}
+
+	switch token.Type {
+	case TokenTypeSubqueryEnd, TokenTypeListEnd, TokenTypeRangeEnd, TokenTypeListSeparator:
+		return false
+	}
+
+	return true
+}
+
+func (f *formatter) isNotPrefix(token *Token) bool {
+	not := f.config.ConnectiveConfig
+	return not.NotPrefix != "" && token.Value == not.NotPrefix && !strings.EqualFold(token.Value, not.Not)
+}
+
+func (f *formatter) text(token *Token) string {
+	switch token.Type {
+	case TokenTypeConnective:
+		if connective, ok := f.config.ConnectiveConfig.connective(token.Value); ok {
+			return f.config.ConnectiveConfig.word(connective)
+		}
+
+	case TokenTypeComparator:
+		if operator, ok := f.config.ComparatorConfig.operator(token.Value); ok {
+			return f.config.ComparatorConfig.comparator(operator)
+		}
+
+	case TokenTypeNegation:
+		if strings.EqualFold(token.Value, f.config.ConnectiveConfig.Not) {
+			return f.config.ConnectiveConfig.Not
+		}
+
+	case TokenTypeFieldName:
+		if field, ok := f.config.Field(token.Value); ok {
+			return field.Name
+		}
+
+	case TokenTypeRangeSeparator:
+		if f.inBrackets {
+			return RangeSeparator
+		}
+
+		return f.config.ConnectiveConfig.And
+
+	case TokenTypeValue, TokenTypeFreeText:
+		return f.value(token)
+	}
+
+	return token.Value
+}
+
+func (f *formatter) value(token *Token) string {
+	switch token.Kind {
+	case ValueKindRegex:
+		if regex, ok := token.TypedValue.(Regex); ok {
+			return regex.String()
+		}
+
+		return Regex{Pattern: token.Value}.String()
+
+	case ValueKindPattern:
+		return token.Value
+	}
+
+	// An open bound of a bracketed range
+	if f.inBrackets && token.isOpenBound() {
+		return token.Value
+	}
+
+	// Values that were quoted stay quoted, so they keep their Quoted flag
+	if token.Quoted || f.needsQuotes(token) {
+		return f.quote(token.Value)
+	}
+
+	return token.Value
+}
+
+/*
+needsQuotes reports whether a value written without quotes would be
+scanned as something other than the same value.
+*/
+func (f *formatter) needsQuotes(token *Token) bool {
+	value := token.Value
+
+	if value == "" || strings.ContainsFunc(value, unicode.IsSpace) {
+		return true
+	}
+
+	if strings.ContainsAny(value, `()\`+string(WildcardAny)+string(WildcardOne)+f.quotes.Quotes+f.quotes.Raw) {
+		return true
+	}
+
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		return true
+	}
+
+	if f.inList && strings.Contains(value, ",") {
+		return true
+	}
+
+	if f.inBrackets && strings.ContainsAny(value, "]}") {
+		return true
+	}
+
+	// Comparators made of words only follow a field name
+	afterField := f.prev != nil && f.prev.Type == TokenTypeFieldName
+
+	for _, configured := range f.config.ComparatorConfig.operators() {
+		comparator := configured.comparator
+
+		if comparator != "" && (afterField || !isWordLike(comparator)) && hasPrefixFold(value, comparator) {
+			return true
+		}
+	}
+
+	if token.Type != TokenTypeFreeText {
+		return false
+	}
+
+	/*
+	 * Free text is scanned where a new condition may start, so it
+	 * must not start with a field name, or be a connective or
+	 * negation. Longer words such as agenda are fine.
+	 */
+	for _, field := range f.config.allFields() {
+		length, ok := prefixLengthFold(value, field.Name)
+		last, _ := utf8.DecodeLastRuneInString(field.Name)
+		next, _ := utf8.DecodeRuneInString(value[length:])
+
+		if ok && !(isWordChar(last) && isWordChar(next)) {
+			return true
+		}
+	}
+
+	for _, word := range []string{f.config.ConnectiveConfig.And, f.config.ConnectiveConfig.Or, f.config.ConnectiveConfig.Not} {
+		if word != "" && strings.EqualFold(value, word) {
+			return true
+		}
+	}
+
+	return f.config.ConnectiveConfig.NotPrefix != "" && hasPrefixFold(value, f.config.ConnectiveConfig.NotPrefix)
+}
+
+func (f *formatter) quote(value string) string {
+	if f.quotes.Quotes == "" {
+		quote := string([]rune(f.quotes.Raw)[0])
+		return quote + value + quote
+	}
+
+	quote := string([]rune(f.quotes.Quotes)[0])
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		quote, `\`+quote,
+		"\n", `\n`,
+		"\t", `\t`,
+		"\r", `\r`,
+	)
+
+	return quote + replacer.Replace(value) + quote
+}
+
+/*
+hasPrefixFold reports whether value starts with prefix, ignoring case
+the same way the scanner does.
+*/
+func hasPrefixFold(value, prefix string) bool {
+	_, ok := prefixLengthFold(value, prefix)
+	return ok
+}
+
+/*
+prefixLengthFold is hasPrefixFold, also returning the number of bytes of
+value that matched prefix.
+*/
+func prefixLengthFold(value, prefix string) (int, bool) {
+	length := 0
+
+	for _, want := range prefix {
+		got, size := utf8.DecodeRuneInString(value[length:])
+
+		if size == 0 || (got != want && !strings.EqualFold(string(got), string(want))) {
+			return 0, false
+		}
+
+		length += size
+	}
+
+	return length, true
+}
+
+/*
+nodeTokens flattens a tree back into the tokens it would be scanned
+from. Trees built by hand may leave tokens out, in which case they are
+made from config.
+*/
+func nodeTokens(node Node, config Config) []*Token {
+	switch n := node.(type) {
+	case *BinaryExpr:
+		connective := n.Token
+
+		if connective == nil {
+			connective = NewToken(TokenTypeConnective, config.ConnectiveConfig.word(n.Connective))
+		}
+
+		result := []*Token{}
+
+		for i, child := range []Node{n.Left, n.Right} {
+			if i > 0 {
+				result = append(result, connective)
+			}
+
+			// Keep an OR beneath an AND together
+			nested, ok := child.(*BinaryExpr)
+
+			if ok && n.Connective == ConnectiveAnd && nested.Connective == ConnectiveOr {
+				result = append(result, groupTokens(child, config)...)
+				continue
+			}
+
+			result = append(result, nodeTokens(child, config)...)
+		}
+
+		return result
+
+	case *Group:
+		return groupTokens(n.Expr, config)
+
+	case *NotExpr:
+		not := n.Token
+
+		if not == nil {
+			not = NewToken(TokenTypeNegation, config.ConnectiveConfig.Not)
+		}
+
+		// A negated connective has to be grouped, or only its left side is negated
+		if _, ok := n.Expr.(*BinaryExpr); ok {
+			return append([]*Token{not}, groupTokens(n.Expr, config)...)
+		}
+
+		return append([]*Token{not}, nodeTokens(n.Expr, config)...)
+
+	case *Comparison:
+		comparator := n.Comparator
+
+		if comparator == nil {
+			comparator = NewToken(TokenTypeComparator, config.ComparatorConfig.comparator(n.Operator))
+		}
+
+		result := []*Token{n.Field, comparator}
+
+		if n.Value != nil {
+			return append(result, n.Value)
+		}
+
+		result = append(result, NewToken(TokenTypeListStart, "("))
+
+		for i, value := range n.Values {
+			if i > 0 {
+				result = append(result, NewToken(TokenTypeListSeparator, ","))
+			}
+
+			result = append(result, value)
+		}
+
+		return append(result, NewToken(TokenTypeListEnd, ")"))
+
+	case *Range:
+		return rangeTokens(n, config)
+
+	case *FreeText:
+		return []*Token{n.Value}
+	}
+
+	return nil
+}
+
+func groupTokens(node Node, config Config) []*Token {
+	result := []*Token{NewToken(TokenTypeSubqueryStart, "(")}
+	result = append(result, nodeTokens(node, config)...)
+
+	return append(result, NewToken(TokenTypeSubqueryEnd, ")"))
+}
+
+/*
+rangeTokens writes a range with BETWEEN when it was written that way and
+both bounds are still inclusive, and in brackets otherwise.
+*/
+func rangeTokens(n *Range, config Config) []*Token {
+	between := config.ComparatorConfig.Between
+
+	if n.Comparator != nil && between != "" && strings.EqualFold(n.Comparator.Value, between) && n.Lower != nil && n.Upper != nil && n.IncludeLower && n.IncludeUpper {
+		return []*Token{
+			n.Field,
+			n.Comparator,
+			n.Lower,
+			NewToken(TokenTypeRangeSeparator, config.ConnectiveConfig.And),
+			n.Upper,
+		}
+	}
+
+	open, end := "{", "}"
+	lower, upper := NewToken(TokenTypeValue, RangeOpenBound), NewToken(TokenTypeValue, RangeOpenBound)
+
+	if n.IncludeLower {
+		open = "["
+	}
+
+	if n.IncludeUpper {
+		end = "]"
+	}
+
+	if n.Lower != nil {
+		lower = n.Lower
+	}
+
+	if n.Upper != nil {
+		upper = n.Upper
+	}
+
+	return []*Token{
+		n.Field,
+		NewToken(TokenTypeComparator, config.ComparatorConfig.Equal),
+		NewToken(TokenTypeRangeStart, open),
+		lower,
+		NewToken(TokenTypeRangeSeparator, RangeSeparator),
+		upper,
+		NewToken(TokenTypeRangeEnd, end),
+	}
+}
@@ -0,0 +1,235 @@
+package searchquerylexer_test
+
+import (
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func formatConfig() sql.Config {
+	connectives := sql.ExtendedConnectiveConfig
+	connectives.NotPrefix = "-"
+
+	return sql.Config{
+		ComparatorConfig:   sql.ExtendedComparatorConfig,
+		ConnectiveConfig:   connectives,
+		QuoteConfig:        sql.DefaultQuoteConfig,
+		ImplicitConnective: sql.ConnectiveAnd,
+		FieldNames: []string{
+			"title",
+			"name",
+			"category",
+		},
+		Fields: []sql.FieldConfig{
+			{Name: "age", Type: sql.FieldTypeInt},
+		},
+	}
+}
+
+func TestFormat(t *testing.T) {
+	config := formatConfig()
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "spacing and casing",
+			input: `TITLE=test   AND (age>=30 OR NAME !=bob)`,
+			want:  `title = test and (age >= 30 or name != bob)`,
+		},
+		{
+			name:  "quotes only when needed",
+			input: `title = a\*b and category = or`,
+			want:  `title = "a*b" and category = or`,
+		},
+		{
+			name:  "quoted values stay quoted",
+			input: `title = 'two words' and name = "a\"b" and category = '30' and age = 30`,
+			want:  `title = "two words" and name = "a\"b" and category = "30" and age = 30`,
+		},
+		{
+			name:  "free text that would not scan back",
+			input: `"title" "and" "age-old" invoice 'a*' agenda`,
+			want:  `"title" "and" "age-old" invoice "a*" agenda`,
+		},
+		{
+			name:  "implicit connectives are left out",
+			input: `title=a age>3 or -name=b`,
+			want:  `title = a age > 3 or -name = b`,
+		},
+		{
+			name:  "negation",
+			input: `NOT (title=a) and -(name=b)`,
+			want:  `not (title = a) and -(name = b)`,
+		},
+		{
+			name:  "lists and ranges",
+			input: `category NOT IN ( a ,"b,c" ) and age BETWEEN 1 AND 5 and name = { a TO * ]`,
+			want:  `category not in (a, "b,c") and age between 1 and 5 and name = {a to *]`,
+		},
+		{
+			name:  "patterns and regular expressions",
+			input: `title = foo\** and name =~ /a\/b/i`,
+			want:  `title = foo\** and name =~ /a\/b/i`,
+		},
+		{
+			name:  "escapes",
+			input: "title = \"tab\\there\" and name = `C:\\temp`",
+			want:  `title = "tab\there" and name = "C:\\temp"`,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := lexer.Tokenize(tt.input)
+			assert.NoError(t, err)
+
+			got := sql.Format(tokens, config)
+			assert.Equal(t, tt.want, got)
+
+			again, err := lexer.Tokenize(got)
+			assert.NoError(t, err)
+			assert.Equal(t, roundTripTokens(tokens, tokens), roundTripTokens(again, tokens))
+		})
+	}
+}
+
+func TestFormatNode(t *testing.T) {
+	config := formatConfig()
+
+	parser, err := sql.NewParser(config)
+	assert.NoError(t, err)
+
+	node, err := parser.Parse(`title=a OR (age between 1 and 3 AND NOT name in (x,y)) invoice`)
+	assert.NoError(t, err)
+
+	got := sql.FormatNode(node, config)
+	assert.Equal(t, `title = a or (age between 1 and 3 and not name in (x, y)) invoice`, got)
+
+	again, err := parser.Parse(got)
+	assert.NoError(t, err)
+	assert.Equal(t, node.String(), again.String())
+
+	t.Run("hand built tree", func(t *testing.T) {
+		node := &sql.BinaryExpr{
+			Connective: sql.ConnectiveAnd,
+			Left: &sql.Comparison{
+				Field:    sql.NewToken(sql.TokenTypeFieldName, "title"),
+				Operator: sql.OperatorLike,
+				Value:    sql.NewToken(sql.TokenTypeValue, "two words"),
+			},
+			Right: &sql.BinaryExpr{
+				Connective: sql.ConnectiveOr,
+				Left:       &sql.NotExpr{Expr: &sql.FreeText{Value: sql.NewToken(sql.TokenTypeFreeText, "x")}},
+				Right: &sql.Range{
+					Field:        sql.NewToken(sql.TokenTypeFieldName, "age"),
+					Lower:        sql.NewToken(sql.TokenTypeValue, "18"),
+					IncludeLower: true,
+				},
+			},
+		}
+
+		got := sql.FormatNode(node, config)
+		assert.Equal(t, `title =~ "two words" and (not x or age = [18 to *})`, got)
+
+		again, err := parser.Parse(got)
+		assert.NoError(t, err)
+		assert.Equal(t, got, sql.FormatNode(again, config))
+	})
+
+	t.Run("hand built negation of a connective", func(t *testing.T) {
+		node := &sql.NotExpr{
+			Expr: &sql.BinaryExpr{
+				Connective: sql.ConnectiveAnd,
+				Left: &sql.Comparison{
+					Field:    sql.NewToken(sql.TokenTypeFieldName, "title"),
+					Operator: sql.OperatorEqual,
+					Value:    sql.NewToken(sql.TokenTypeValue, "a"),
+				},
+				Right: &sql.Comparison{
+					Field:    sql.NewToken(sql.TokenTypeFieldName, "age"),
+					Operator: sql.OperatorEqual,
+					Value:    sql.NewToken(sql.TokenTypeValue, "3"),
+				},
+			},
+		}
+
+		got := sql.FormatNode(node, config)
+		assert.Equal(t, `not (title = a and age = 3)`, got)
+
+		again, err := parser.Parse(got)
+		assert.NoError(t, err)
+		assert.Equal(t, `not(group((title eq "a" and age eq "3")))`, again.String())
+	})
+}
+
+func FuzzFormat(f *testing.F) {
+	config := formatConfig()
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(f, err)
+
+	seeds := []string{
+		`title = test`,
+		`TITLE="test"   AND (age>=30 OR NAME !=bob)`,
+		`title = 'two words' and name = "a\"b" and category = "=x"`,
+		`"title" "and" invoice 'a*' -name = b`,
+		`category NOT IN ( a ,"b,c" ) and age BETWEEN 1 AND 5 and name = { a TO * ]`,
+		`title = foo\** and name =~ /a\/b/i`,
+		"title = `raw\\` and name = \"\\u00e9\\n\"",
+		`not (title = a or not name = b) c d`,
+	}
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		tokens, err := lexer.Tokenize(input)
+
+		if err != nil {
+			return
+		}
+
+		formatted := sql.Format(tokens, config)
+		again, err := lexer.Tokenize(formatted)
+
+		if err != nil {
+			t.Fatalf("formatting %q gave %q, which does not tokenize: %s", input, formatted, err)
+		}
+
+		if !assert.Equal(t, roundTripTokens(tokens, tokens), roundTripTokens(again, tokens)) {
+			t.Fatalf("formatting %q gave %q, which tokenizes differently", input, formatted)
+		}
+	})
+}
+
+/*
+roundTripTokens strips what formatting is allowed to change from tokens,
+which is their position. Formatting may also quote a value that was
+not quoted, when it has to, but never the other way around. Pass the
+original tokens as quoted so that only values quoted in both are
+compared as quoted.
+*/
+func roundTripTokens(tokens []*sql.Token, quoted []*sql.Token) []sql.Token {
+	result := make([]sql.Token, 0, len(tokens))
+
+	for i, token := range tokens {
+		result = append(result, sql.Token{
+			Type:       token.Type,
+			Value:      token.Value,
+			TypedValue: token.TypedValue,
+			Kind:       token.Kind,
+			Quoted:     token.Quoted && i < len(quoted) && quoted[i].Quoted,
+			Implicit:   token.Implicit,
+		})
+	}
+
+	return result
+}
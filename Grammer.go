@@ -3,12 +3,78 @@ package searchquerylexer
 type TokenType string
 
 const (
-	TokenEmpty             TokenType = "[empty]"
-	TokenTypeValue         TokenType = "[value]"
-	TokenTypeComparator    TokenType = "[comparator]"
-	TokenTypeFieldName     TokenType = "[fieldName]"
-	TokenTypeSubqueryStart TokenType = "[subQueryStart]"
-	TokenTypeSubqueryEnd   TokenType = "[subQueryEnd]"
-	TokenTypeConnective    TokenType = "[connective]"
-	TokenEOF               TokenType = "[eof]"
+	TokenEmpty              TokenType = "[empty]"
+	TokenTypeValue          TokenType = "[value]"
+	TokenTypeFreeText       TokenType = "[freeText]"
+	TokenTypeComparator     TokenType = "[comparator]"
+	TokenTypeFieldName      TokenType = "[fieldName]"
+	TokenTypeSubqueryStart  TokenType = "[subQueryStart]"
+	TokenTypeSubqueryEnd    TokenType = "[subQueryEnd]"
+	TokenTypeConnective     TokenType = "[connective]"
+	TokenTypeNegation       TokenType = "[negation]"
+	TokenTypeListStart      TokenType = "[listStart]"
+	TokenTypeListSeparator  TokenType = "[listSeparator]"
+	TokenTypeListEnd        TokenType = "[listEnd]"
+	TokenTypeRangeStart     TokenType = "[rangeStart]"
+	TokenTypeRangeSeparator TokenType = "[rangeSeparator]"
+	TokenTypeRangeEnd       TokenType = "[rangeEnd]"
+	TokenEOF                TokenType = "[eof]"
+)
+
+/*
+endsCondition reports whether a token of this type can be the last
+token of a condition, and so be followed by a connective.
+*/
+func (t TokenType) endsCondition() bool {
+	switch t {
+	case TokenTypeValue, TokenTypeFreeText, TokenTypeSubqueryEnd, TokenTypeListEnd, TokenTypeRangeEnd:
+		return true
+	}
+
+	return false
+}
+
+const (
+	// RangeSeparator separates the bounds of a bracketed range, as in [1 TO 5]
+	RangeSeparator = "to"
+	// RangeOpenBound leaves a bound of a bracketed range open, as in [1 TO *]
+	RangeOpenBound = "*"
+)
+
+type Operator string
+
+const (
+	OperatorEqual              Operator = "eq"
+	OperatorNotEqual           Operator = "ne"
+	OperatorLessThan           Operator = "lt"
+	OperatorGreaterThan        Operator = "gt"
+	OperatorLessThanEqualTo    Operator = "lte"
+	OperatorGreaterThanEqualTo Operator = "gte"
+	OperatorLike               Operator = "like"
+	OperatorNotLike            Operator = "notlike"
+	OperatorIn                 Operator = "in"
+	OperatorNotIn              Operator = "notin"
+	OperatorBetween            Operator = "between"
+)
+
+func (o Operator) valid() bool {
+	switch o {
+	case OperatorEqual, OperatorNotEqual, OperatorLessThan, OperatorGreaterThan,
+		OperatorLessThanEqualTo, OperatorGreaterThanEqualTo, OperatorLike, OperatorNotLike,
+		OperatorIn, OperatorNotIn, OperatorBetween:
+		return true
+	}
+
+	return false
+}
+
+func (o Operator) takesList() bool {
+	return o == OperatorIn || o == OperatorNotIn
+}
+
+type Connective string
+
+const (
+	ConnectiveAnd Connective = "and"
+	ConnectiveOr  Connective = "or"
 )
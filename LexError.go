@@ -0,0 +1,139 @@
+package searchquerylexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+LexError describes a problem found while lexing or parsing a search
+query. Err is the underlying cause, so errors.Is works against sentinels
+such as ErrInvalidEscapeSequence. Offset is a byte offset into Input,
+and Line and Column are 1-based, with Column counting runes.
+*/
+type LexError struct {
+	Input   string
+	Offset  int
+	Line    int
+	Column  int
+	Err     error
+	Message string
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+func (e *LexError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Pretty renders the line of input the error occurred on with a caret
+pointing at the offending position. The caret is aligned by display
+column, so wide characters such as CJK take up two columns and
+combining marks take up none.
+
+	INPUT: title="\atest"
+	               │
+	               └ invalid escape sequence
+*/
+func (e *LexError) Pretty() string {
+	prefix := "INPUT: "
+	offset := min(e.Offset, len(e.Input))
+	lineStart := strings.LastIndex(e.Input[:offset], "\n") + 1
+	lineEnd := strings.Index(e.Input[lineStart:], "\n")
+
+	if lineEnd < 0 {
+		lineEnd = len(e.Input)
+	} else {
+		lineEnd += lineStart
+	}
+
+	width := displayWidth(e.Input[lineStart:offset]) + 1 + len(prefix)
+
+	s := prefix + e.Input[lineStart:lineEnd] + "\n"
+	s += fmt.Sprintf("%*s\n", width, "│")
+	s += fmt.Sprintf("%*s %s\n", width, "└", e.Message)
+
+	return s
+}
+
+type lexErrorJSON struct {
+	Input   string `json:"input"`
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *LexError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toJSON())
+}
+
+func (e *LexError) toJSON() lexErrorJSON {
+	return lexErrorJSON{
+		Input:   e.Input,
+		Offset:  e.Offset,
+		Line:    e.Line,
+		Column:  e.Column,
+		Error:   e.Err.Error(),
+		Message: e.Message,
+	}
+}
+
+func lineAndColumn(input string, offset int) (int, int) {
+	lineStart := strings.LastIndex(input[:offset], "\n") + 1
+
+	line := strings.Count(input[:offset], "\n") + 1
+	column := utf8.RuneCountInString(input[lineStart:offset]) + 1
+
+	return line, column
+}
+
+func displayWidth(s string) int {
+	result := 0
+
+	for _, ch := range s {
+		result += runeWidth(ch)
+	}
+
+	return result
+}
+
+func runeWidth(ch rune) int {
+	if unicode.In(ch, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+
+	if unicode.In(ch, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || isWideRune(ch) {
+		return 2
+	}
+
+	return 1
+}
+
+/*
+isWideRune covers the East Asian Wide and Fullwidth blocks that are not
+matched by script, such as CJK punctuation, fullwidth forms, and emoji.
+*/
+func isWideRune(ch rune) bool {
+	return (ch >= 0x1100 && ch <= 0x115F) ||
+		(ch >= 0x2E80 && ch <= 0x303E) ||
+		(ch >= 0x3041 && ch <= 0x33FF) ||
+		(ch >= 0x3400 && ch <= 0x4DBF) ||
+		(ch >= 0x4E00 && ch <= 0x9FFF) ||
+		(ch >= 0xA000 && ch <= 0xA4CF) ||
+		(ch >= 0xAC00 && ch <= 0xD7A3) ||
+		(ch >= 0xF900 && ch <= 0xFAFF) ||
+		(ch >= 0xFE30 && ch <= 0xFE4F) ||
+		(ch >= 0xFF00 && ch <= 0xFF60) ||
+		(ch >= 0xFFE0 && ch <= 0xFFE6) ||
+		(ch >= 0x1F300 && ch <= 0x1F64F) ||
+		(ch >= 0x1F900 && ch <= 0x1F9FF) ||
+		(ch >= 0x20000 && ch <= 0x3FFFD)
+}
@@ -2,7 +2,10 @@ package searchquerylexer_test
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	sql "github.com/adampresley/search-query-lexer"
 	"github.com/stretchr/testify/assert"
@@ -11,8 +14,8 @@ import (
 func TestNewLexer(t *testing.T) {
 	t.Run("success with config", func(t *testing.T) {
 		config := sql.Config{
-			ComparatorConfig: sql.DefaultComparatorConfig,
-			ConnectiveConfig: sql.DefaultConnectiveConfig,
+			ComparatorConfig: sql.ExtendedComparatorConfig,
+			ConnectiveConfig: sql.ExtendedConnectiveConfig,
 			FieldNames: []string{
 				"title",
 				"name",
@@ -24,12 +27,109 @@ func TestNewLexer(t *testing.T) {
 		assert.NoError(t, err)
 		assert.IsType(t, &sql.Lexer{}, lexer)
 	})
+
+	t.Run("duplicate field names", func(t *testing.T) {
+		config := sql.Config{
+			ComparatorConfig: sql.ExtendedComparatorConfig,
+			ConnectiveConfig: sql.ExtendedConnectiveConfig,
+			FieldNames: []string{
+				"title",
+			},
+			Fields: []sql.FieldConfig{
+				{Name: "Title", Column: "documents.title_text"},
+			},
+		}
+
+		_, err := sql.NewLexer(config)
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+	})
+
+	t.Run("duplicate comparators", func(t *testing.T) {
+		var (
+			configErr *sql.ConfigError
+		)
+
+		for _, change := range []func(c *sql.ComparatorConfig){
+			func(c *sql.ComparatorConfig) { c.Like = "=" },
+			func(c *sql.ComparatorConfig) { c.Between = "IN" },
+		} {
+			config := sql.Config{
+				ComparatorConfig: sql.ExtendedComparatorConfig,
+				ConnectiveConfig: sql.ExtendedConnectiveConfig,
+			}
+
+			change(&config.ComparatorConfig)
+
+			_, err := sql.NewLexer(config)
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigComparator)
+			assert.ErrorAs(t, err, &configErr)
+		}
+
+		assert.Equal(t, "comparators.between", configErr.Path)
+	})
+
+	t.Run("invalid implicit connective", func(t *testing.T) {
+		config := sql.Config{
+			ComparatorConfig:   sql.ExtendedComparatorConfig,
+			ConnectiveConfig:   sql.ExtendedConnectiveConfig,
+			ImplicitConnective: "xor",
+		}
+
+		_, err := sql.NewLexer(config)
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigConnective)
+	})
+
+	t.Run("default fields must be configured strings", func(t *testing.T) {
+		config := sql.Config{
+			ComparatorConfig: sql.ExtendedComparatorConfig,
+			ConnectiveConfig: sql.ExtendedConnectiveConfig,
+			FieldNames:       []string{"title"},
+			Fields: []sql.FieldConfig{
+				{Name: "age", Type: sql.FieldTypeInt},
+			},
+			DefaultFields: []string{"title", "body"},
+		}
+
+		_, err := sql.NewLexer(config)
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		config.DefaultFields = []string{"Title", "age"}
+
+		_, err = sql.NewLexer(config)
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+	})
+
+	t.Run("invalid quotes", func(t *testing.T) {
+		for _, quotes := range []sql.QuoteConfig{{Quotes: `""`}, {Quotes: `"`, Raw: `"`}, {Quotes: "("}, {Raw: "a"}} {
+			config := sql.Config{
+				ComparatorConfig: sql.ExtendedComparatorConfig,
+				ConnectiveConfig: sql.ExtendedConnectiveConfig,
+				QuoteConfig:      quotes,
+			}
+
+			_, err := sql.NewLexer(config)
+			assert.ErrorIs(t, err, sql.ErrInvalidConfigQuote)
+		}
+	})
+
+	t.Run("unknown field comparator", func(t *testing.T) {
+		config := sql.Config{
+			ComparatorConfig: sql.ExtendedComparatorConfig,
+			ConnectiveConfig: sql.ExtendedConnectiveConfig,
+			Fields: []sql.FieldConfig{
+				{Name: "age", Comparators: []sql.Operator{"bogus"}},
+			},
+		}
+
+		_, err := sql.NewLexer(config)
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+	})
 }
 
 func TestTokenize(t *testing.T) {
 	defaultConfig := sql.Config{
-		ComparatorConfig: sql.DefaultComparatorConfig,
-		ConnectiveConfig: sql.DefaultConnectiveConfig,
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
 		FieldNames: []string{
 			"title",
 			"name",
@@ -61,6 +161,19 @@ func TestTokenize(t *testing.T) {
 		},
 	}
 
+	fieldConfig := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		Fields: []sql.FieldConfig{
+			{Name: "title", Column: "documents.title_text"},
+			{Name: "age", Comparators: []sql.Operator{sql.OperatorEqual, sql.OperatorGreaterThanEqualTo}},
+		},
+	}
+
+	negationConfig := alternateConfig
+	negationConfig.ConnectiveConfig.Not = "NOT"
+	negationConfig.ConnectiveConfig.NotPrefix = "-"
+
 	table := []struct {
 		name        string
 		input       string
@@ -127,9 +240,35 @@ func TestTokenize(t *testing.T) {
 			name:  "value and value",
 			input: "yummy and sweet",
 			want: []*sql.Token{
-				sql.NewToken(sql.TokenTypeValue, "yummy"),
+				sql.NewToken(sql.TokenTypeFreeText, "yummy"),
 				sql.NewToken(sql.TokenTypeConnective, "and"),
-				sql.NewToken(sql.TokenTypeValue, "sweet"),
+				sql.NewToken(sql.TokenTypeFreeText, "sweet"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "free text beside a comparison",
+			input: `invoice and title = "invoice"`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFreeText, "invoice"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "invoice"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "free text starting with a field name",
+			input: "agenda and titles and age > 3",
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFreeText, "agenda"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFreeText, "titles"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFieldName, "age"),
+				sql.NewToken(sql.TokenTypeComparator, ">"),
+				sql.NewToken(sql.TokenTypeValue, "3"),
 			},
 			config: defaultConfig,
 		},
@@ -137,9 +276,9 @@ func TestTokenize(t *testing.T) {
 			name:  "value or value",
 			input: "salty or sweet",
 			want: []*sql.Token{
-				sql.NewToken(sql.TokenTypeValue, "salty"),
+				sql.NewToken(sql.TokenTypeFreeText, "salty"),
 				sql.NewToken(sql.TokenTypeConnective, "or"),
-				sql.NewToken(sql.TokenTypeValue, "sweet"),
+				sql.NewToken(sql.TokenTypeFreeText, "sweet"),
 			},
 			config: defaultConfig,
 		},
@@ -228,6 +367,266 @@ func TestTokenize(t *testing.T) {
 			},
 			config: alternateConfig,
 		},
+		{
+			name:  "field config",
+			input: "title =~ test and age >= 30",
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "=~"),
+				sql.NewToken(sql.TokenTypeValue, "test"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFieldName, "age"),
+				sql.NewToken(sql.TokenTypeComparator, ">="),
+				sql.NewToken(sql.TokenTypeValue, "30"),
+			},
+			config: fieldConfig,
+		},
+		{
+			name:        "comparator not allowed for field",
+			input:       "title =~ test and age =~ 30",
+			want:        nil,
+			wantErr:     true,
+			expectedErr: sql.ErrComparatorNotAllowed,
+			config:      fieldConfig,
+		},
+		{
+			name:  "not before subquery",
+			input: `title = a and NOT (category = "bad")`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "a"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeNegation, "not"),
+				sql.NewToken(sql.TokenTypeSubqueryStart, "("),
+				sql.NewToken(sql.TokenTypeFieldName, "category"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "bad"),
+				sql.NewToken(sql.TokenTypeSubqueryEnd, ")"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "not before field",
+			input: `not title = a`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeNegation, "not"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "a"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "not as a value",
+			input: `title = not`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "not"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "not prefix",
+			input: `-title:draft && -(age:>3 || role:-1)`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeNegation, "-"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, ":"),
+				sql.NewToken(sql.TokenTypeValue, "draft"),
+				sql.NewToken(sql.TokenTypeConnective, "&&"),
+				sql.NewToken(sql.TokenTypeNegation, "-"),
+				sql.NewToken(sql.TokenTypeSubqueryStart, "("),
+				sql.NewToken(sql.TokenTypeFieldName, "age"),
+				sql.NewToken(sql.TokenTypeComparator, ":>"),
+				sql.NewToken(sql.TokenTypeValue, "3"),
+				sql.NewToken(sql.TokenTypeConnective, "||"),
+				sql.NewToken(sql.TokenTypeFieldName, "role"),
+				sql.NewToken(sql.TokenTypeComparator, ":"),
+				sql.NewToken(sql.TokenTypeValue, "-1"),
+				sql.NewToken(sql.TokenTypeSubqueryEnd, ")"),
+			},
+			config: negationConfig,
+		},
+		{
+			name:        "dangling not",
+			input:       "title = a and not   ",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidNegation,
+			config:      defaultConfig,
+		},
+		{
+			name:        "not before a comparator",
+			input:       "NOT = 5",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidNegation,
+			config:      defaultConfig,
+		},
+		{
+			name:  "not before free text",
+			input: `NOT 5 && -invoice && -"past due"`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeNegation, "NOT"),
+				sql.NewToken(sql.TokenTypeFreeText, "5"),
+				sql.NewToken(sql.TokenTypeConnective, "&&"),
+				sql.NewToken(sql.TokenTypeNegation, "-"),
+				sql.NewToken(sql.TokenTypeFreeText, "invoice"),
+				sql.NewToken(sql.TokenTypeConnective, "&&"),
+				sql.NewToken(sql.TokenTypeNegation, "-"),
+				sql.NewToken(sql.TokenTypeFreeText, "past due"),
+			},
+			config: negationConfig,
+		},
+		{
+			name:  "in list",
+			input: `category IN ("a", b,"c d") and title not in (x)`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "category"),
+				sql.NewToken(sql.TokenTypeComparator, "in"),
+				sql.NewToken(sql.TokenTypeListStart, "("),
+				sql.NewToken(sql.TokenTypeValue, "a"),
+				sql.NewToken(sql.TokenTypeListSeparator, ","),
+				sql.NewToken(sql.TokenTypeValue, "b"),
+				sql.NewToken(sql.TokenTypeListSeparator, ","),
+				sql.NewToken(sql.TokenTypeValue, "c d"),
+				sql.NewToken(sql.TokenTypeListEnd, ")"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "not in"),
+				sql.NewToken(sql.TokenTypeListStart, "("),
+				sql.NewToken(sql.TokenTypeValue, "x"),
+				sql.NewToken(sql.TokenTypeListEnd, ")"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "in is not a comparator inside a word",
+			input: `title = inbox or title=in`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "inbox"),
+				sql.NewToken(sql.TokenTypeConnective, "or"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "in"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:        "in without a list",
+			input:       `category in a`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidList,
+			config:      defaultConfig,
+		},
+		{
+			name:        "empty list",
+			input:       `category in ( )`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidList,
+			config:      defaultConfig,
+		},
+		{
+			name:        "trailing separator in list",
+			input:       `category in (a, )`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidList,
+			config:      defaultConfig,
+		},
+		{
+			name:        "missing separator in list",
+			input:       `category in (a b)`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidList,
+			config:      defaultConfig,
+		},
+		{
+			name:        "unterminated list",
+			input:       `category in (a, b`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidList,
+			config:      defaultConfig,
+		},
+		{
+			name:  "between range",
+			input: `age BETWEEN 18 AND 30 and title = a`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "age"),
+				sql.NewToken(sql.TokenTypeComparator, "between"),
+				sql.NewToken(sql.TokenTypeValue, "18"),
+				sql.NewToken(sql.TokenTypeRangeSeparator, "and"),
+				sql.NewToken(sql.TokenTypeValue, "30"),
+				sql.NewToken(sql.TokenTypeConnective, "and"),
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, "a"),
+			},
+			config: defaultConfig,
+		},
+		{
+			name:  "bracket ranges",
+			input: `age:[18 TO 30] || (name:{2024-01-01 to *})`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "age"),
+				sql.NewToken(sql.TokenTypeComparator, ":"),
+				sql.NewToken(sql.TokenTypeRangeStart, "["),
+				sql.NewToken(sql.TokenTypeValue, "18"),
+				sql.NewToken(sql.TokenTypeRangeSeparator, "to"),
+				sql.NewToken(sql.TokenTypeValue, "30"),
+				sql.NewToken(sql.TokenTypeRangeEnd, "]"),
+				sql.NewToken(sql.TokenTypeConnective, "||"),
+				sql.NewToken(sql.TokenTypeSubqueryStart, "("),
+				sql.NewToken(sql.TokenTypeFieldName, "name"),
+				sql.NewToken(sql.TokenTypeComparator, ":"),
+				sql.NewToken(sql.TokenTypeRangeStart, "{"),
+				sql.NewToken(sql.TokenTypeValue, "2024-01-01"),
+				sql.NewToken(sql.TokenTypeRangeSeparator, "to"),
+				sql.NewToken(sql.TokenTypeValue, "*"),
+				sql.NewToken(sql.TokenTypeRangeEnd, "}"),
+				sql.NewToken(sql.TokenTypeSubqueryEnd, ")"),
+			},
+			config: alternateConfig,
+		},
+		{
+			name:        "between without and",
+			input:       `age between 18 or 30`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidRange,
+			config:      defaultConfig,
+		},
+		{
+			name:        "range without separator",
+			input:       `age = [18 30]`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidRange,
+			config:      defaultConfig,
+		},
+		{
+			name:        "unterminated range",
+			input:       `age = [18 to 30`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidRange,
+			config:      defaultConfig,
+		},
+		{
+			name:        "range not allowed for field",
+			input:       `age = [18 to 30]`,
+			wantErr:     true,
+			expectedErr: sql.ErrComparatorNotAllowed,
+			config:      fieldConfig,
+		},
+		{
+			name:  "escaped quotes",
+			input: `title = "say \"hi\" \\o/"`,
+			want: []*sql.Token{
+				sql.NewToken(sql.TokenTypeFieldName, "title"),
+				sql.NewToken(sql.TokenTypeComparator, "="),
+				sql.NewToken(sql.TokenTypeValue, `say "hi" \o/`),
+			},
+			config: defaultConfig,
+		},
 		{
 			name:        "invalid escape sequence error",
 			input:       `title="\atest"`,
@@ -244,6 +643,34 @@ func TestTokenize(t *testing.T) {
 			expectedErr: sql.ErrInvalidConnective,
 			config:      defaultConfig,
 		},
+		{
+			name:        "trailing and after a connective",
+			input:       "title = a and and",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidConnective,
+			config:      defaultConfig,
+		},
+		{
+			name:        "trailing or after a connective",
+			input:       "title = a and or",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidConnective,
+			config:      defaultConfig,
+		},
+		{
+			name:        "connective before the end of a subquery",
+			input:       "(title = a and) or title = b",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidConnective,
+			config:      defaultConfig,
+		},
+		{
+			name:        "not before the end of a subquery",
+			input:       "title = a and (not)",
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidNegation,
+			config:      defaultConfig,
+		},
 	}
 
 	for _, tt := range table {
@@ -261,8 +688,638 @@ func TestTokenize(t *testing.T) {
 					fmt.Printf("%s\n", t.String())
 				}
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.want, withoutPositions(got))
 			}
 		})
 	}
 }
+
+func TestTokenizePositions(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"age",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	got, err := lexer.Tokenize("title=~\"test\"\n  and (age >= 30)")
+	assert.NoError(t, err)
+
+	want := []*sql.Token{
+		{Type: sql.TokenTypeFieldName, Value: "title", Start: 0, End: 5, Line: 1, Column: 1, EndLine: 1, EndColumn: 6},
+		{Type: sql.TokenTypeComparator, Value: "=~", Start: 5, End: 7, Line: 1, Column: 6, EndLine: 1, EndColumn: 8},
+		{Type: sql.TokenTypeValue, Value: "test", TypedValue: "test", Quoted: true, Start: 7, End: 13, Line: 1, Column: 8, EndLine: 1, EndColumn: 14},
+		{Type: sql.TokenTypeConnective, Value: "and", Start: 16, End: 19, Line: 2, Column: 3, EndLine: 2, EndColumn: 6},
+		{Type: sql.TokenTypeSubqueryStart, Value: "(", Start: 20, End: 21, Line: 2, Column: 7, EndLine: 2, EndColumn: 8},
+		{Type: sql.TokenTypeFieldName, Value: "age", Start: 21, End: 24, Line: 2, Column: 8, EndLine: 2, EndColumn: 11},
+		{Type: sql.TokenTypeComparator, Value: ">=", Start: 25, End: 27, Line: 2, Column: 12, EndLine: 2, EndColumn: 14},
+		{Type: sql.TokenTypeValue, Value: "30", TypedValue: "30", Start: 28, End: 30, Line: 2, Column: 15, EndLine: 2, EndColumn: 17},
+		{Type: sql.TokenTypeSubqueryEnd, Value: ")", Start: 30, End: 31, Line: 2, Column: 17, EndLine: 2, EndColumn: 18},
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestTokenizeImplicitConnective(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"age",
+		},
+		ImplicitConnective: sql.ConnectiveAnd,
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	got, err := lexer.Tokenize(`title="foo" age>3`)
+	assert.NoError(t, err)
+
+	want := []*sql.Token{
+		{Type: sql.TokenTypeFieldName, Value: "title", Start: 0, End: 5, Line: 1, Column: 1, EndLine: 1, EndColumn: 6},
+		{Type: sql.TokenTypeComparator, Value: "=", Start: 5, End: 6, Line: 1, Column: 6, EndLine: 1, EndColumn: 7},
+		{Type: sql.TokenTypeValue, Value: "foo", TypedValue: "foo", Quoted: true, Start: 6, End: 11, Line: 1, Column: 7, EndLine: 1, EndColumn: 12},
+		{Type: sql.TokenTypeConnective, Value: "and", Implicit: true, Start: 12, End: 12, Line: 1, Column: 13, EndLine: 1, EndColumn: 13},
+		{Type: sql.TokenTypeFieldName, Value: "age", Start: 12, End: 15, Line: 1, Column: 13, EndLine: 1, EndColumn: 16},
+		{Type: sql.TokenTypeComparator, Value: ">", Start: 15, End: 16, Line: 1, Column: 16, EndLine: 1, EndColumn: 17},
+		{Type: sql.TokenTypeValue, Value: "3", TypedValue: "3", Start: 16, End: 17, Line: 1, Column: 17, EndLine: 1, EndColumn: 18},
+	}
+
+	assert.Equal(t, want, got)
+
+	t.Run("explicit connectives are kept", func(t *testing.T) {
+		got, err := lexer.Tokenize(`title="foo" or age>3`)
+		assert.NoError(t, err)
+		assert.Len(t, got, 7)
+		assert.Equal(t, "or", got[3].Value)
+		assert.False(t, got[3].Implicit)
+	})
+
+	t.Run("before subqueries and negations", func(t *testing.T) {
+		orConfig := config
+		orConfig.ImplicitConnective = sql.ConnectiveOr
+
+		orLexer, err := sql.NewLexer(orConfig)
+		assert.NoError(t, err)
+
+		got, err := orLexer.Tokenize(`(title=a) not age<3`)
+		assert.NoError(t, err)
+
+		want := []*sql.Token{
+			sql.NewToken(sql.TokenTypeSubqueryStart, "("),
+			sql.NewToken(sql.TokenTypeFieldName, "title"),
+			sql.NewToken(sql.TokenTypeComparator, "="),
+			sql.NewToken(sql.TokenTypeValue, "a"),
+			sql.NewToken(sql.TokenTypeSubqueryEnd, ")"),
+			sql.NewToken(sql.TokenTypeConnective, "or"),
+			sql.NewToken(sql.TokenTypeNegation, "not"),
+			sql.NewToken(sql.TokenTypeFieldName, "age"),
+			sql.NewToken(sql.TokenTypeComparator, "<"),
+			sql.NewToken(sql.TokenTypeValue, "3"),
+		}
+
+		assert.Equal(t, want, withoutPositions(got))
+		assert.True(t, got[5].Implicit)
+	})
+
+	t.Run("trailing connectives and negations", func(t *testing.T) {
+		errTable := []struct {
+			input       string
+			expectedErr error
+		}{
+			{input: "title = a and", expectedErr: sql.ErrInvalidConnective},
+			{input: "title = a or", expectedErr: sql.ErrInvalidConnective},
+			{input: "title = a and and", expectedErr: sql.ErrInvalidConnective},
+			{input: "(title = a or) and age = 3", expectedErr: sql.ErrInvalidConnective},
+			{input: "title = a not", expectedErr: sql.ErrInvalidNegation},
+			{input: "title = a and (not)", expectedErr: sql.ErrInvalidNegation},
+		}
+
+		for _, tt := range errTable {
+			_, err := lexer.Tokenize(tt.input)
+			assert.ErrorIs(t, err, tt.expectedErr, tt.input)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		defaultConfig := config
+		defaultConfig.ImplicitConnective = ""
+
+		defaultLexer, err := sql.NewLexer(defaultConfig)
+		assert.NoError(t, err)
+
+		got, err := defaultLexer.Tokenize(`title="foo" age>3`)
+		assert.NoError(t, err)
+		assert.Len(t, got, 6)
+	})
+}
+
+func TestTokenizePatterns(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"name",
+		},
+		Fields: []sql.FieldConfig{
+			{Name: "age", Type: sql.FieldTypeInt},
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		name        string
+		input       string
+		wantValue   string
+		wantKind    sql.ValueKind
+		wantPattern sql.Pattern
+		expectedErr error
+	}{
+		{
+			name:        "trailing wildcard",
+			input:       "title=foo*",
+			wantValue:   "foo*",
+			wantKind:    sql.ValueKindPattern,
+			wantPattern: sql.Pattern{{Literal: "foo"}, {Wildcard: sql.WildcardAny}},
+		},
+		{
+			name:        "single character wildcard",
+			input:       "name=j?n",
+			wantValue:   "j?n",
+			wantKind:    sql.ValueKindPattern,
+			wantPattern: sql.Pattern{{Literal: "j"}, {Wildcard: sql.WildcardOne}, {Literal: "n"}},
+		},
+		{
+			name:        "escaped wildcard in a pattern",
+			input:       `title!=\**`,
+			wantValue:   `\**`,
+			wantKind:    sql.ValueKindPattern,
+			wantPattern: sql.Pattern{{Literal: "*"}, {Wildcard: sql.WildcardAny}},
+		},
+		{
+			name:      "escaped wildcards only",
+			input:     `title=what\?`,
+			wantValue: "what?",
+		},
+		{
+			name:      "quoted values are literal",
+			input:     `title="foo*"`,
+			wantValue: "foo*",
+		},
+		{
+			name:      "wildcards are literal with other comparators",
+			input:     "title > foo*",
+			wantValue: "foo*",
+		},
+		{
+			name:        "free text",
+			input:       "inv*",
+			wantValue:   "inv*",
+			wantKind:    sql.ValueKindPattern,
+			wantPattern: sql.Pattern{{Literal: "inv"}, {Wildcard: sql.WildcardAny}},
+		},
+		{
+			name:        "wildcards in typed fields",
+			input:       "age=3*",
+			expectedErr: sql.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lexer.Tokenize(tt.input)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			value := got[len(got)-1]
+
+			assert.Equal(t, tt.wantValue, value.Value)
+			assert.Equal(t, tt.wantKind, value.Kind)
+
+			if tt.wantPattern != nil {
+				assert.Equal(t, tt.wantPattern, value.TypedValue)
+			}
+		})
+	}
+}
+
+func TestTokenizeRegex(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"message",
+		},
+		Fields: []sql.FieldConfig{
+			{Name: "age", Type: sql.FieldTypeInt},
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	t.Run("pattern and flags", func(t *testing.T) {
+		got, err := lexer.Tokenize(`message =~ /timeout after \d+ms/i and message != /a\/b/`)
+		assert.NoError(t, err)
+		assert.Len(t, got, 7)
+
+		first, ok := got[2].TypedValue.(sql.Regex)
+
+		assert.True(t, ok)
+		assert.Equal(t, sql.ValueKindRegex, got[2].Kind)
+		assert.Equal(t, `timeout after \d+ms`, got[2].Value)
+		assert.Equal(t, "i", first.Flags)
+		assert.Equal(t, `(?i)timeout after \d+ms`, first.Expression())
+		assert.True(t, first.Regexp.MatchString("Timeout after 30ms"))
+		assert.Equal(t, 11, got[2].Start)
+		assert.Equal(t, 33, got[2].End)
+
+		second := got[6].TypedValue.(sql.Regex)
+
+		assert.Equal(t, "a/b", second.Pattern)
+		assert.Equal(t, `/a\/b/`, second.String())
+	})
+
+	t.Run("slashes elsewhere are values", func(t *testing.T) {
+		got, err := lexer.Tokenize(`message > /a/b`)
+		assert.NoError(t, err)
+		assert.Equal(t, "/a/b", got[2].Value)
+		assert.Empty(t, got[2].Kind)
+	})
+
+	table := []struct {
+		name       string
+		input      string
+		wantOffset int
+	}{
+		{name: "invalid pattern", input: `message =~ /a(b/`, wantOffset: 11},
+		{name: "unterminated", input: `message =~ /abc`, wantOffset: 11},
+		{name: "unknown flag", input: `message =~ /abc/ix`, wantOffset: 17},
+		{name: "typed field", input: `age = /3/`, wantOffset: 6},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lexer.Tokenize(tt.input)
+
+			var lexErr *sql.LexError
+
+			assert.ErrorAs(t, err, &lexErr)
+			assert.Equal(t, tt.wantOffset, lexErr.Offset)
+
+			if tt.name == "typed field" {
+				assert.ErrorIs(t, err, sql.ErrInvalidValue)
+			} else {
+				assert.ErrorIs(t, err, sql.ErrInvalidRegex)
+			}
+		})
+	}
+}
+
+func TestTokenizeQuotes(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		QuoteConfig:      sql.DefaultQuoteConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		name        string
+		input       string
+		want        string
+		wantQuoted  bool
+		expectedErr error
+	}{
+		{name: "double quotes", input: `title = "it's"`, want: "it's", wantQuoted: true},
+		{name: "single quotes", input: `title = 'say "hi"'`, want: `say "hi"`, wantQuoted: true},
+		{name: "escaped single quote", input: `title = 'it\'s'`, want: "it's", wantQuoted: true},
+		{name: "standard escapes", input: `title = "a\tb\nc\\"`, want: "a\tb\nc\\", wantQuoted: true},
+		{name: "unicode escape", input: `title = "caf\u00e9"`, want: "café", wantQuoted: true},
+		{name: "raw string", input: "title = `C:\\new\\\"`", want: `C:\new\"`, wantQuoted: true},
+		{name: "bare value", input: `title = 30`, want: "30"},
+		{name: "short unicode escape", input: `title = "\u00e"`, expectedErr: sql.ErrInvalidEscapeSequence},
+		{name: "surrogate unicode escape", input: `title = "\ud800"`, expectedErr: sql.ErrInvalidEscapeSequence},
+		{name: "unknown escape", input: `title = "\a"`, expectedErr: sql.ErrInvalidEscapeSequence},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lexer.Tokenize(tt.input)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, got, 3)
+			assert.Equal(t, tt.want, got[2].Value)
+			assert.Equal(t, tt.wantQuoted, got[2].Quoted)
+		})
+	}
+
+	t.Run("single quotes are not quotes by default", func(t *testing.T) {
+		config.QuoteConfig = sql.QuoteConfig{}
+
+		lexer, err := sql.NewLexer(config)
+		assert.NoError(t, err)
+
+		got, err := lexer.Tokenize(`title = 'abc'`)
+		assert.NoError(t, err)
+		assert.Equal(t, "'abc'", got[2].Value)
+		assert.False(t, got[2].Quoted)
+	})
+}
+
+func TestTokenizeTypedValues(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		Fields: []sql.FieldConfig{
+			{Name: "title"},
+			{Name: "age", Type: sql.FieldTypeInt},
+			{Name: "score", Type: sql.FieldTypeFloat},
+			{Name: "active", Type: sql.FieldTypeBool},
+			{Name: "created", Type: sql.FieldTypeTime},
+			{Name: "timeout", Type: sql.FieldTypeDuration},
+			{Name: "status", Type: sql.FieldTypeEnum, Values: []string{"Open", "Closed"}},
+			{Name: "id", Type: sql.FieldTypeUUID},
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		name        string
+		input       string
+		want        any
+		expectedErr error
+	}{
+		{name: "string", input: `title = "30"`, want: "30"},
+		{name: "int", input: "age >= 30", want: int64(30)},
+		{name: "float", input: "score < 2.5", want: 2.5},
+		{name: "bool", input: "active = true", want: true},
+		{name: "date", input: "created > 2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "time", input: "created > 2024-01-02T03:04:05Z", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "duration", input: "timeout > 1m30s", want: 90 * time.Second},
+		{name: "enum", input: "status = open", want: "Open"},
+		{name: "uuid", input: "id = 0D3C5A4E-3B1F-4C8B-9A6E-2F1D0C9B8A7E", want: "0d3c5a4e-3b1f-4c8b-9a6e-2f1d0c9b8a7e"},
+		{name: "like keeps the string", input: "age =~ 3", want: "3"},
+		{name: "invalid int", input: "age >= abc", expectedErr: sql.ErrInvalidValue},
+		{name: "invalid int in list", input: "age in (1, x)", expectedErr: sql.ErrInvalidValue},
+		{name: "invalid int in range", input: "age between 1 and x", expectedErr: sql.ErrInvalidValue},
+		{name: "invalid enum", input: "status = pending", expectedErr: sql.ErrInvalidValue},
+		{name: "invalid uuid", input: "id = 1234", expectedErr: sql.ErrInvalidValue},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lexer.Tokenize(tt.input)
+
+			if tt.expectedErr != nil {
+				var lexErr *sql.LexError
+
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.ErrorAs(t, err, &lexErr)
+				assert.Equal(t, strings.LastIndexAny(tt.input, " (")+1, lexErr.Offset)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got[2].TypedValue)
+			}
+		})
+	}
+}
+
+func TestTokenizeUTF8(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ComparatorConfig{
+			Equal:              "≡",
+			NotEqual:           "≠",
+			LessThan:           "<",
+			GreaterThan:        ">",
+			LessThanEqualTo:    "≤",
+			GreaterThanEqualTo: "≥",
+			Like:               "ÄHNLICH",
+			NotLike:            "!ÄHNLICH",
+		},
+		ConnectiveConfig: sql.ConnectiveConfig{
+			And: "und",
+			Or:  "oder",
+		},
+		FieldNames: []string{
+			"título",
+			"名前",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	t.Run("tokens", func(t *testing.T) {
+		got, err := lexer.Tokenize("TÍTULO ähnlich \"Café\"\u00a0UND 名前 ≠ 東京")
+		assert.NoError(t, err)
+
+		want := []*sql.Token{
+			{Type: sql.TokenTypeFieldName, Value: "título", Start: 0, End: 7, Line: 1, Column: 1, EndLine: 1, EndColumn: 7},
+			{Type: sql.TokenTypeComparator, Value: "ÄHNLICH", Start: 8, End: 16, Line: 1, Column: 8, EndLine: 1, EndColumn: 15},
+			{Type: sql.TokenTypeValue, Value: "Café", TypedValue: "Café", Quoted: true, Start: 17, End: 24, Line: 1, Column: 16, EndLine: 1, EndColumn: 22},
+			{Type: sql.TokenTypeConnective, Value: "und", Start: 26, End: 29, Line: 1, Column: 23, EndLine: 1, EndColumn: 26},
+			{Type: sql.TokenTypeFieldName, Value: "名前", Start: 30, End: 36, Line: 1, Column: 27, EndLine: 1, EndColumn: 29},
+			{Type: sql.TokenTypeComparator, Value: "≠", Start: 37, End: 40, Line: 1, Column: 30, EndLine: 1, EndColumn: 31},
+			{Type: sql.TokenTypeValue, Value: "東京", TypedValue: "東京", Start: 41, End: 47, Line: 1, Column: 32, EndLine: 1, EndColumn: 34},
+		}
+
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("caret is aligned by display column", func(t *testing.T) {
+		_, err := lexer.Tokenize(`名前 ≡ "東京\a"`)
+
+		var lexErr *sql.LexError
+
+		assert.ErrorAs(t, err, &lexErr)
+		assert.Equal(t, 10, lexErr.Column)
+
+		want := "INPUT: 名前 ≡ \"東京\\a\"\n" +
+			"                    │\n" +
+			"                    └ invalid escape sequence\n"
+
+		assert.Equal(t, want, lexErr.Pretty())
+	})
+}
+
+func TestLexerConcurrentUse(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		Fields: []sql.FieldConfig{
+			{Name: "title"},
+			{Name: "age", Type: sql.FieldTypeInt},
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	parser, err := sql.NewParser(config)
+	assert.NoError(t, err)
+
+	inputs := map[string]int{
+		`title = "test"`: 3,
+		`(title =~ test and age >= 30) or age < 3`: 13,
+		`age > 1 and age < 100`:                    7,
+	}
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		for input, want := range inputs {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for range 50 {
+					got, err := lexer.Tokenize(input)
+					assert.NoError(t, err)
+					assert.Len(t, got, want)
+
+					_, err = parser.Parse(input)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestTokenizeDoesNotLeakState(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	_, err = lexer.Tokenize("title =")
+	assert.NoError(t, err)
+
+	// The comparator ending the previous input must not make this a value
+	got, err := lexer.Tokenize("title = test")
+	assert.NoError(t, err)
+	assert.Equal(t, sql.TokenTypeFieldName, got[0].Type)
+}
+
+func withoutPositions(tokens []*sql.Token) []*sql.Token {
+	result := make([]*sql.Token, 0, len(tokens))
+
+	for _, t := range tokens {
+		result = append(result, sql.NewToken(t.Type, t.Value))
+	}
+
+	return result
+}
+
+func TestLexError(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	_, err = lexer.Tokenize(`title="\atest"`)
+
+	var lexErr *sql.LexError
+
+	assert.ErrorAs(t, err, &lexErr)
+	assert.ErrorIs(t, err, sql.ErrInvalidEscapeSequence)
+	assert.Equal(t, 8, lexErr.Offset)
+	assert.Equal(t, 1, lexErr.Line)
+	assert.Equal(t, 9, lexErr.Column)
+	assert.Equal(t, "invalid escape sequence", lexErr.Message)
+	assert.Equal(t, "1:9: invalid escape sequence", lexErr.Error())
+
+	want := "INPUT: title=\"\\atest\"\n" +
+		"               │\n" +
+		"               └ invalid escape sequence\n"
+
+	assert.Equal(t, want, lexErr.Pretty())
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		QuoteConfig:      sql.DefaultQuoteConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		name        string
+		input       string
+		wantOffset  int
+		expectedErr error
+	}{
+		{name: "double quoted", input: `title="unterminated`, wantOffset: 6, expectedErr: sql.ErrUnterminatedString},
+		{name: "single quoted", input: `title = 'a\'`, wantOffset: 8, expectedErr: sql.ErrUnterminatedString},
+		{name: "raw", input: "title = `a", wantOffset: 8, expectedErr: sql.ErrUnterminatedString},
+		{name: "free text", input: `title = a or "b`, wantOffset: 13, expectedErr: sql.ErrUnterminatedString},
+		{name: "unclosed subquery", input: `(title = a or (title = b)`, wantOffset: 0, expectedErr: sql.ErrUnbalancedParentheses},
+		{name: "innermost unclosed subquery", input: `(title = a or (title = b`, wantOffset: 14, expectedErr: sql.ErrUnbalancedParentheses},
+		{name: "unopened subquery", input: `title = a) or title = b`, wantOffset: 9, expectedErr: sql.ErrUnbalancedParentheses},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := lexer.Tokenize(tt.input)
+
+			var lexErr *sql.LexError
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.ErrorAs(t, err, &lexErr)
+			assert.Equal(t, tt.wantOffset, lexErr.Offset)
+		})
+	}
+
+	t.Run("balanced", func(t *testing.T) {
+		_, err := lexer.Tokenize(`((title = a) or title = "(b")`)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,262 @@
+package searchquerylexer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+/*
+Diagnostic is a problem found by Lint. Errors are problems Tokenize
+would fail on. Warnings are queries that lex, but probably do not mean
+what was intended, such as a field name used as a value.
+*/
+type Diagnostic struct {
+	Severity Severity
+	*LexError
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Severity Severity `json:"severity"`
+		lexErrorJSON
+	}{
+		Severity:     d.Severity,
+		lexErrorJSON: d.toJSON(),
+	})
+}
+
+/*
+Lint scans all of input, rather than stopping at the first error like
+Tokenize does. After an error it skips ahead to the next whitespace or
+closing parenthesis and carries on. An error within a list or range
+skips the rest of it instead, up to its closing bracket or the next
+connective. It returns every token it could
+scan, along with errors and warnings ordered by their position in input.
+*/
+func (l *Lexer) Lint(input string) ([]*Token, []Diagnostic) {
+	var (
+		lexErr *LexError
+	)
+
+	s := l.newScanner(input)
+	tokens := make([]*Token, 0, 50)
+	diagnostics := []Diagnostic{}
+
+	for {
+		token, err := s.next()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			if !errors.As(err, &lexErr) {
+				lexErr = s.captureLinterError(err).(*LexError)
+			}
+
+			diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, LexError: lexErr})
+
+			if !s.resync(lexErr.Offset) {
+				break
+			}
+
+			continue
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	diagnostics = append(diagnostics, s.warnings(tokens, diagnostics)...)
+
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		return a.Offset - b.Offset
+	})
+
+	return tokens, diagnostics
+}
+
+/*
+resync skips past the error at offset to the next whitespace or closing
+parenthesis, and forgets any list or range the error happened in. Within
+a list or range it skips to the end of it instead, so that its closing
+bracket is not left over. It returns false when there is nothing left
+to scan.
+*/
+func (s *scanner) resync(offset int) bool {
+	pos := s.currentPos
+
+	if offset < len(s.input) {
+		_, size := utf8.DecodeRuneInString(s.input[offset:])
+		pos = max(pos, offset+size)
+	}
+
+	switch {
+	case s.inList:
+		pos = s.skipPast(pos, ")")
+
+	case s.inRange && s.rangeBrackets:
+		pos = s.skipPast(pos, "]}")
+
+	case s.inRange:
+		pos = s.skipPast(pos, "")
+
+		// An error in the lower bound comes before the AND between the bounds
+		if s.prevToken != nil && s.prevToken.Type == TokenTypeComparator {
+			if length, ok := s.matchAt(pos, s.config.ConnectiveConfig.And); ok {
+				pos = s.skipPast(pos+length, "")
+			}
+		}
+
+	default:
+		for pos < len(s.input) {
+			ch, size := utf8.DecodeRuneInString(s.input[pos:])
+
+			if unicode.IsSpace(ch) || ch == ')' {
+				break
+			}
+
+			pos += size
+		}
+	}
+
+	s.currentPos = pos
+	s.currentToken = EmptyToken()
+	s.nextToken = nil
+	s.inList = false
+	s.inRange = false
+
+	return pos < len(s.input)
+}
+
+/*
+skipPast skips from pos to just past the first of closers, or to the
+start of the next connective, whichever comes first. Quoted strings are
+skipped whole, so a closer within one does not count.
+*/
+func (s *scanner) skipPast(pos int, closers string) int {
+	for pos < len(s.input) {
+		ch, size := utf8.DecodeRuneInString(s.input[pos:])
+
+		switch {
+		case strings.ContainsRune(closers, ch):
+			return pos + size
+
+		case strings.ContainsRune(s.quotes.Quotes+s.quotes.Raw, ch):
+			pos = s.skipQuoted(pos)
+			continue
+
+		case unicode.IsSpace(ch):
+			pos += size
+
+			if s.isConnectiveAt(pos) {
+				return pos
+			}
+
+			continue
+		}
+
+		pos += size
+	}
+
+	return pos
+}
+
+/*
+skipQuoted returns the offset just past the string starting with the
+quote at pos, or the end of input when it is not terminated.
+*/
+func (s *scanner) skipQuoted(pos int) int {
+	quote, size := utf8.DecodeRuneInString(s.input[pos:])
+	raw := strings.ContainsRune(s.quotes.Raw, quote)
+
+	for pos += size; pos < len(s.input); {
+		ch, size := utf8.DecodeRuneInString(s.input[pos:])
+		pos += size
+
+		if ch == quote {
+			return pos
+		}
+
+		if ch == '\\' && !raw && pos < len(s.input) {
+			_, size = utf8.DecodeRuneInString(s.input[pos:])
+			pos += size
+		}
+	}
+
+	return pos
+}
+
+func (s *scanner) isConnectiveAt(pos int) bool {
+	for _, connectiveName := range s.connectiveList {
+		if length, ok := s.matchAt(pos, connectiveName); ok && (pos+length == len(s.input) || s.isWhitespaceAt(pos+length)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+warnings looks for problems that do not stop a query from lexing. A
+comparator whose value could not be scanned is not warned about, as
+failures already covers it.
+*/
+func (s *scanner) warnings(tokens []*Token, failures []Diagnostic) []Diagnostic {
+	var (
+		result []Diagnostic
+	)
+
+	warn := func(token *Token, err error) {
+		lexErr := s.captureLinterErrorAt(token.Start, err).(*LexError)
+		result = append(result, Diagnostic{Severity: SeverityWarning, LexError: lexErr})
+	}
+
+	for i, token := range tokens {
+		switch token.Type {
+		case TokenTypeValue:
+			if !token.Quoted && token.Kind == "" {
+				if field, ok := s.config.Field(token.Value); ok {
+					warn(token, fmt.Errorf("'%s' is a field name, quote it to search for it as a value: %w", field.Name, ErrFieldNameAsValue))
+				}
+			}
+
+		case TokenTypeComparator:
+			if i == 0 || tokens[i-1].Type != TokenTypeFieldName {
+				warn(token, fmt.Errorf("comparator '%s' has no field before it: %w", token.Value, ErrDanglingComparator))
+			}
+
+			end := len(s.input)
+
+			if i < len(tokens)-1 {
+				end = tokens[i+1].Start
+			}
+
+			failed := slices.ContainsFunc(failures, func(d Diagnostic) bool {
+				return d.Offset >= token.End && d.Offset <= end
+			})
+
+			if !failed && (i == len(tokens)-1 || !startsComparedValue(tokens[i+1].Type)) {
+				warn(token, fmt.Errorf("comparator '%s' has no value after it: %w", token.Value, ErrDanglingComparator))
+			}
+		}
+	}
+
+	return result
+}
+
+func startsComparedValue(t TokenType) bool {
+	return t == TokenTypeValue || t == TokenTypeListStart || t == TokenTypeRangeStart
+}
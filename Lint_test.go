@@ -0,0 +1,156 @@
+package searchquerylexer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"name",
+		},
+		Fields: []sql.FieldConfig{
+			{Name: "age", Type: sql.FieldTypeInt},
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	type diagnostic struct {
+		severity sql.Severity
+		offset   int
+		err      error
+	}
+
+	table := []struct {
+		name            string
+		input           string
+		wantTokens      int
+		wantDiagnostics []diagnostic
+	}{
+		{
+			name:       "valid query",
+			input:      `title = a and age > 3`,
+			wantTokens: 7,
+		},
+		{
+			name:       "several errors",
+			input:      `age > abc and title="\a" or name) = b and title = c`,
+			wantTokens: 13,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 6, err: sql.ErrInvalidValue},
+				{severity: sql.SeverityError, offset: 22, err: sql.ErrInvalidEscapeSequence},
+				{severity: sql.SeverityError, offset: 32, err: sql.ErrUnbalancedParentheses},
+			},
+		},
+		{
+			name:       "error within a list",
+			input:      `title in (a b) and age = 3`,
+			wantTokens: 8,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 12, err: sql.ErrInvalidList},
+			},
+		},
+		{
+			name:       "error within a bracketed range",
+			input:      `age = [1 x 2]`,
+			wantTokens: 4,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 9, err: sql.ErrInvalidRange},
+			},
+		},
+		{
+			name:       "error within a list stops at the next connective",
+			input:      `title in (a b or name = "c)"`,
+			wantTokens: 8,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 12, err: sql.ErrInvalidList},
+			},
+		},
+		{
+			name:       "error in the lower bound of between",
+			input:      `age between x and 5 and title = a`,
+			wantTokens: 6,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 12, err: sql.ErrInvalidValue},
+			},
+		},
+		{
+			name:       "field name used as value",
+			input:      `title = name or title = "name"`,
+			wantTokens: 7,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityWarning, offset: 8, err: sql.ErrFieldNameAsValue},
+			},
+		},
+		{
+			name:       "dangling comparator",
+			input:      `= a and name =`,
+			wantTokens: 5,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityWarning, offset: 0, err: sql.ErrDanglingComparator},
+				{severity: sql.SeverityWarning, offset: 13, err: sql.ErrDanglingComparator},
+			},
+		},
+		{
+			name:       "unterminated string at the end",
+			input:      `title = a and name = "b`,
+			wantTokens: 6,
+			wantDiagnostics: []diagnostic{
+				{severity: sql.SeverityError, offset: 21, err: sql.ErrUnterminatedString},
+			},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, diagnostics := lexer.Lint(tt.input)
+
+			assert.Len(t, tokens, tt.wantTokens)
+			assert.Len(t, diagnostics, len(tt.wantDiagnostics))
+
+			for i, want := range tt.wantDiagnostics {
+				if i >= len(diagnostics) {
+					break
+				}
+
+				assert.Equal(t, want.severity, diagnostics[i].Severity)
+				assert.Equal(t, want.offset, diagnostics[i].Offset)
+				assert.ErrorIs(t, diagnostics[i], want.err)
+			}
+		})
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	_, diagnostics := lexer.Lint(`title = title`)
+	assert.Len(t, diagnostics, 1)
+
+	got, err := json.Marshal(diagnostics[0])
+	assert.NoError(t, err)
+
+	want := `{"severity":"warning","input":"title = title","offset":8,"line":1,"column":9,` +
+		`"error":"'title' is a field name, quote it to search for it as a value: field name used as value",` +
+		`"message":"'title' is a field name, quote it to search for it as a value: field name used as value"}`
+
+	assert.JSONEq(t, want, string(got))
+}
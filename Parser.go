@@ -0,0 +1,392 @@
+package searchquerylexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+Parser turns the tokens produced by a Lexer into a tree of nodes,
+pairing fields with their comparators and values, honoring subquery
+nesting, and giving AND precedence over OR.
+*/
+type Parser struct {
+	lexer *Lexer
+}
+
+/*
+parser holds the state of a single call to Parse or ParseTokens, which
+keeps Parser safe for concurrent use.
+*/
+type parser struct {
+	lexer     *Lexer
+	input     string
+	current   *Token
+	nextToken func() (*Token, error)
+}
+
+func NewParser(config Config) (*Parser, error) {
+	lexer, err := NewLexer(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Parser{
+		lexer: lexer,
+	}
+
+	return result, nil
+}
+
+func (p *Parser) Parse(input string) (Node, error) {
+	state := &parser{
+		lexer:     p.lexer,
+		input:     input,
+		nextToken: p.lexer.Stream(input).Next,
+	}
+
+	return state.parse()
+}
+
+/*
+ParseTokens builds a tree from tokens that have already been produced
+by Lexer.Tokenize. Because the original input is not available, errors
+carry token positions but no input to render.
+*/
+func (p *Parser) ParseTokens(tokens []*Token) (Node, error) {
+	index := 0
+	eof := NewToken(TokenEOF, "")
+	eof.Line = 1
+	eof.Column = 1
+
+	if len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+
+		eof.Start = last.End
+		eof.End = last.End
+		eof.Line = last.EndLine
+		eof.Column = last.EndColumn
+	}
+
+	state := &parser{
+		lexer: p.lexer,
+		nextToken: func() (*Token, error) {
+			if index >= len(tokens) {
+				return eof, io.EOF
+			}
+
+			index++
+			return tokens[index-1], nil
+		},
+	}
+
+	return state.parse()
+}
+
+func (p *parser) parse() (Node, error) {
+	var (
+		err    error
+		result Node
+	)
+
+	if err = p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.current.Type == TokenEOF {
+		return nil, p.unexpected("expected a search expression")
+	}
+
+	if result, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+
+	if p.current.Type != TokenEOF {
+		return nil, p.unexpected("expected a connective")
+	}
+
+	return result, nil
+}
+
+func (p *parser) advance() error {
+	token, err := p.nextToken()
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	p.current = token
+	return nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary(ConnectiveOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary(ConnectiveAnd, p.parsePrimary)
+}
+
+func (p *parser) parseBinary(connective Connective, parseOperand func() (Node, error)) (Node, error) {
+	left, err := parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isConnective(connective) {
+		token := p.current
+
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &BinaryExpr{
+			Connective: connective,
+			Token:      token,
+			Left:       left,
+			Right:      right,
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	switch p.current.Type {
+	case TokenTypeSubqueryStart:
+		return p.parseGroup()
+
+	case TokenTypeFieldName:
+		return p.parseComparison()
+
+	case TokenTypeNegation:
+		return p.parseNot()
+
+	case TokenTypeFreeText:
+		result := &FreeText{Value: p.current}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	return nil, p.unexpected("expected a field name, search term, or subquery")
+}
+
+func (p *parser) parseGroup() (Node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.current.Type != TokenTypeSubqueryEnd {
+		return nil, p.unexpected("expected end of subquery")
+	}
+
+	if err = p.advance(); err != nil {
+		return nil, err
+	}
+
+	return &Group{Expr: expr}, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	result := &NotExpr{
+		Token: p.current,
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parsePrimary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Expr = expr
+	return result, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	result := &Comparison{
+		Field: p.current,
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.current.Type != TokenTypeComparator {
+		return nil, p.unexpected(fmt.Sprintf("expected a comparator after field '%s'", result.Field.Value))
+	}
+
+	result.Comparator = p.current
+	result.Operator, _ = p.lexer.config.ComparatorConfig.operator(p.current.Value)
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if result.Operator.takesList() {
+		return p.parseList(result)
+	}
+
+	if result.Operator == OperatorBetween || p.current.Type == TokenTypeRangeStart {
+		return p.parseRange(result)
+	}
+
+	if p.current.Type != TokenTypeValue {
+		return nil, p.unexpected(fmt.Sprintf("expected a value after comparator '%s'", result.Comparator.Value))
+	}
+
+	result.Value = p.current
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *parser) parseList(result *Comparison) (Node, error) {
+	if p.current.Type != TokenTypeListStart {
+		return nil, p.unexpected(fmt.Sprintf("expected a list after comparator '%s'", result.Comparator.Value))
+	}
+
+	for {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.current.Type != TokenTypeValue {
+			return nil, p.unexpected("expected a value in list")
+		}
+
+		result.Values = append(result.Values, p.current)
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.current.Type == TokenTypeListEnd {
+			break
+		}
+
+		if p.current.Type != TokenTypeListSeparator {
+			return nil, p.unexpected("expected ',' or ')' in list")
+		}
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *parser) parseRange(comparison *Comparison) (Node, error) {
+	result := &Range{
+		Field:        comparison.Field,
+		Comparator:   comparison.Comparator,
+		IncludeLower: true,
+		IncludeUpper: true,
+	}
+
+	brackets := p.current.Type == TokenTypeRangeStart
+
+	if brackets {
+		result.IncludeLower = p.current.Value == "["
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	bounds := []**Token{&result.Lower, &result.Upper}
+
+	for i, bound := range bounds {
+		if p.current.Type != TokenTypeValue {
+			return nil, p.unexpected("expected a value in range")
+		}
+
+		if !brackets || !p.current.isOpenBound() {
+			*bound = p.current
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			if p.current.Type != TokenTypeRangeSeparator {
+				return nil, p.unexpected("expected a separator in range")
+			}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if brackets {
+		if p.current.Type != TokenTypeRangeEnd {
+			return nil, p.unexpected("expected the end of the range")
+		}
+
+		result.IncludeUpper = p.current.Value == "]"
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (p *parser) isConnective(connective Connective) bool {
+	if p.current.Type != TokenTypeConnective {
+		return false
+	}
+
+	match, ok := p.lexer.config.ConnectiveConfig.connective(p.current.Value)
+	return ok && match == connective
+}
+
+func (p *parser) unexpected(message string) error {
+	var (
+		err error
+	)
+
+	if p.current.Type == TokenEOF {
+		err = fmt.Errorf("%s: %w", message, ErrUnexpectedEndOfInput)
+	} else {
+		err = fmt.Errorf("%s, found %s '%s': %w", message, p.current.Type, p.current.Value, ErrUnexpectedToken)
+	}
+
+	return &LexError{
+		Input:   p.input,
+		Offset:  p.current.Start,
+		Line:    p.current.Line,
+		Column:  p.current.Column,
+		Err:     err,
+		Message: p.lexer.prettyError(err),
+	}
+}
@@ -0,0 +1,268 @@
+package searchquerylexer_test
+
+import (
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	defaultConfig := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"name",
+			"age",
+			"category",
+		},
+	}
+
+	table := []struct {
+		name        string
+		input       string
+		want        string
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name:  "single comparison",
+			input: "title = testing",
+			want:  `title eq "testing"`,
+		},
+		{
+			name:  "and binds tighter than or",
+			input: `title = a OR name != "b" AND age >= 30`,
+			want:  `(title eq "a" or (name ne "b" and age gte "30"))`,
+		},
+		{
+			name:  "and chains are left associative",
+			input: "title = a and name = b and age < 3",
+			want:  `((title eq "a" and name eq "b") and age lt "3")`,
+		},
+		{
+			name:  "subqueries",
+			input: `(title=~"test" AND age >= 30) OR (category != "bad")`,
+			want:  `(group((title like "test" and age gte "30")) or group(category ne "bad"))`,
+		},
+		{
+			name:  "subquery overrides precedence",
+			input: `title = a and (name = b or name = c)`,
+			want:  `(title eq "a" and group((name eq "b" or name eq "c")))`,
+		},
+		{
+			name:  "negation",
+			input: `not title = a or not (age > 3 and not category = b)`,
+			want:  `(not(title eq "a") or not(group((age gt "3" and not(category eq "b")))))`,
+		},
+		{
+			name:  "double negation",
+			input: `not not title = a`,
+			want:  `not(not(title eq "a"))`,
+		},
+		{
+			name:  "in list",
+			input: `category in (a, "b c") or category not in (d)`,
+			want:  `(category in ["a", "b c"] or category notin ["d"])`,
+		},
+		{
+			name:  "between",
+			input: `age between 18 and 30 and title = a`,
+			want:  `(age between ["18", "30"] and title eq "a")`,
+		},
+		{
+			name:  "bracket ranges",
+			input: `age = {18 to 30] or age = [* to 5}`,
+			want:  `(age between {"18", "30"] or age between [*, "5"})`,
+		},
+		{
+			name:  "quoted open bound is a value",
+			input: `age = ["*" to 5]`,
+			want:  `age between ["*", "5"]`,
+		},
+		{
+			name:  "free text",
+			input: `invoice or "past due" and age > 3`,
+			want:  `(text("invoice") or (text("past due") and age gt "3"))`,
+		},
+		{
+			name:  "negated free text",
+			input: `not invoice and not (title = a)`,
+			want:  `(not(text("invoice")) and not(group(title eq "a")))`,
+		},
+		{
+			name:        "empty input",
+			input:       "   ",
+			wantErr:     true,
+			expectedErr: sql.ErrUnexpectedEndOfInput,
+		},
+		{
+			name:        "missing comparator",
+			input:       "title testing",
+			wantErr:     true,
+			expectedErr: sql.ErrUnexpectedToken,
+		},
+		{
+			name:        "missing value",
+			input:       "title =",
+			wantErr:     true,
+			expectedErr: sql.ErrUnexpectedEndOfInput,
+		},
+		{
+			name:        "unclosed subquery",
+			input:       "(title = a",
+			wantErr:     true,
+			expectedErr: sql.ErrUnbalancedParentheses,
+		},
+		{
+			name:        "adjacent comparisons",
+			input:       "title = a name = b",
+			wantErr:     true,
+			expectedErr: sql.ErrUnexpectedToken,
+		},
+		{
+			name:        "lexer errors are returned",
+			input:       `title="\atest"`,
+			wantErr:     true,
+			expectedErr: sql.ErrInvalidEscapeSequence,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := sql.NewParser(defaultConfig)
+			assert.NoError(t, err)
+
+			got, err := parser.Parse(tt.input)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"age",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	parser, err := sql.NewParser(config)
+	assert.NoError(t, err)
+
+	tokens, err := lexer.Tokenize(`title = "test" and (age > 3 or age < 1)`)
+	assert.NoError(t, err)
+
+	got, err := parser.ParseTokens(tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, `(title eq "test" and group((age gt "3" or age lt "1")))`, got.String())
+
+	tokens, err = lexer.Tokenize(`title = "test" and age > 3`)
+	assert.NoError(t, err)
+
+	_, err = parser.ParseTokens(tokens[:4])
+
+	var lexErr *sql.LexError
+
+	assert.ErrorIs(t, err, sql.ErrUnexpectedEndOfInput)
+	assert.ErrorAs(t, err, &lexErr)
+	assert.Equal(t, 18, lexErr.Offset)
+	assert.Equal(t, 19, lexErr.Column)
+
+	t.Run("end of input after a multibyte token", func(t *testing.T) {
+		config.FieldNames = []string{"título"}
+
+		lexer, err := sql.NewLexer(config)
+		assert.NoError(t, err)
+
+		parser, err := sql.NewParser(config)
+		assert.NoError(t, err)
+
+		tokens, err := lexer.Tokenize("título = 1 and\ntítulo = 2")
+		assert.NoError(t, err)
+
+		_, err = parser.ParseTokens(tokens[:5])
+
+		var lexErr *sql.LexError
+
+		assert.ErrorIs(t, err, sql.ErrUnexpectedEndOfInput)
+		assert.ErrorAs(t, err, &lexErr)
+		assert.Equal(t, 23, lexErr.Offset)
+		assert.Equal(t, 2, lexErr.Line)
+		assert.Equal(t, 7, lexErr.Column)
+	})
+}
+
+func TestParseImplicitConnective(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.ExtendedComparatorConfig,
+		ConnectiveConfig: sql.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"name",
+			"age",
+		},
+		ImplicitConnective: sql.ConnectiveAnd,
+	}
+
+	parser, err := sql.NewParser(config)
+	assert.NoError(t, err)
+
+	got, err := parser.Parse(`title="foo" age>3 or name = b (age < 1 or age > 9)`)
+	assert.NoError(t, err)
+	assert.Equal(t, `((title eq "foo" and age gt "3") or (name eq "b" and group((age lt "1" or age gt "9"))))`, got.String())
+}
+
+func TestParseDefaultConfig(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.DefaultComparatorConfig,
+		ConnectiveConfig: sql.DefaultConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"category",
+			"age",
+		},
+	}
+
+	parser, err := sql.NewParser(config)
+	assert.NoError(t, err)
+
+	table := []struct {
+		input string
+		want  string
+	}{
+		{input: "title = not", want: `title eq "not"`},
+		{input: "category = in and age = between", want: `(category eq "in" and age eq "between")`},
+		{input: "title != not and category = a", want: `(title ne "not" and category eq "a")`},
+	}
+
+	for _, tt := range table {
+		got, err := parser.Parse(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got.String(), tt.input)
+	}
+
+	t.Run("extended keywords are opt in", func(t *testing.T) {
+		config.ComparatorConfig = sql.ExtendedComparatorConfig
+		config.ConnectiveConfig = sql.ExtendedConnectiveConfig
+
+		parser, err := sql.NewParser(config)
+		assert.NoError(t, err)
+
+		got, err := parser.Parse("not title = a and category in (b)")
+		assert.NoError(t, err)
+		assert.Equal(t, `(not(title eq "a") and category in ["b"])`, got.String())
+	})
+}
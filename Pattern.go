@@ -0,0 +1,163 @@
+package searchquerylexer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// WildcardAny matches any run of characters, including none
+	WildcardAny rune = '*'
+	// WildcardOne matches exactly one character
+	WildcardOne rune = '?'
+)
+
+/*
+Pattern is a value containing wildcards, such as foo* or j?n. It is a
+sequence of parts, each of which is either literal text or a single
+wildcard. A backslash in front of a wildcard, as in what\?, makes it
+literal.
+*/
+type Pattern []PatternPart
+
+/*
+PatternPart is one piece of a Pattern. Exactly one of Literal or
+Wildcard is set.
+*/
+type PatternPart struct {
+	Literal  string
+	Wildcard rune
+}
+
+/*
+parsePattern splits source into literal text and wildcards, and reports
+whether there were any unescaped wildcards.
+*/
+func parsePattern(source string) (Pattern, bool) {
+	var (
+		result      Pattern
+		literal     strings.Builder
+		hasWildcard bool
+	)
+
+	for i := 0; i < len(source); {
+		ch, size := utf8.DecodeRuneInString(source[i:])
+		i += size
+
+		if ch == '\\' && i < len(source) && (source[i] == byte(WildcardAny) || source[i] == byte(WildcardOne)) {
+			literal.WriteByte(source[i])
+			i++
+			continue
+		}
+
+		if ch != WildcardAny && ch != WildcardOne {
+			literal.WriteRune(ch)
+			continue
+		}
+
+		if literal.Len() > 0 {
+			result = append(result, PatternPart{Literal: literal.String()})
+			literal.Reset()
+		}
+
+		result = append(result, PatternPart{Wildcard: ch})
+		hasWildcard = true
+	}
+
+	if literal.Len() > 0 {
+		result = append(result, PatternPart{Literal: literal.String()})
+	}
+
+	return result, hasWildcard
+}
+
+/*
+String returns the pattern as it would be written in a query, with
+literal wildcard characters escaped.
+*/
+func (p Pattern) String() string {
+	var result strings.Builder
+
+	for _, part := range p {
+		if part.Wildcard != 0 {
+			result.WriteRune(part.Wildcard)
+			continue
+		}
+
+		result.WriteString(escapeWildcards(part.Literal))
+	}
+
+	return result.String()
+}
+
+/*
+Regexp returns an anchored regular expression matching the same values
+as the pattern.
+*/
+func (p Pattern) Regexp() string {
+	return "(?s)^" + p.regexpBody() + "$"
+}
+
+/*
+ContainsRegexp returns an unanchored regular expression matching values
+that contain the pattern anywhere within them.
+*/
+func (p Pattern) ContainsRegexp() string {
+	return "(?s)" + p.regexpBody()
+}
+
+func (p Pattern) regexpBody() string {
+	var result strings.Builder
+
+	for _, part := range p {
+		switch part.Wildcard {
+		case WildcardAny:
+			result.WriteString(".*")
+
+		case WildcardOne:
+			result.WriteString(".")
+
+		default:
+			result.WriteString(regexp.QuoteMeta(part.Literal))
+		}
+	}
+
+	return result.String()
+}
+
+/*
+Prefix reports whether the pattern is literal text followed by a single
+trailing WildcardAny, as in foo*, and returns that text.
+*/
+func (p Pattern) Prefix() (string, bool) {
+	if len(p) != 2 || p[0].Literal == "" || p[1].Wildcard != WildcardAny {
+		return "", false
+	}
+
+	return p[0].Literal, true
+}
+
+func (p Pattern) text() string {
+	var result strings.Builder
+
+	for _, part := range p {
+		if part.Wildcard != 0 {
+			result.WriteRune(part.Wildcard)
+			continue
+		}
+
+		result.WriteString(part.Literal)
+	}
+
+	return result.String()
+}
+
+func escapeWildcards(value string) string {
+	replacer := strings.NewReplacer(
+		string(WildcardAny), `\`+string(WildcardAny),
+		string(WildcardOne), `\`+string(WildcardOne),
+	)
+
+	return replacer.Replace(value)
+}
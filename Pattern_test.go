@@ -0,0 +1,39 @@
+package searchquerylexer_test
+
+import (
+	"regexp"
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPattern(t *testing.T) {
+	prefix := sql.Pattern{{Literal: "foo"}, {Wildcard: sql.WildcardAny}}
+	middle := sql.Pattern{{Literal: "a.*"}, {Wildcard: sql.WildcardOne}, {Literal: "c"}}
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "foo*", prefix.String())
+		assert.Equal(t, `a.\*?c`, middle.String())
+	})
+
+	t.Run("Regexp", func(t *testing.T) {
+		assert.Equal(t, `(?s)^foo.*$`, prefix.Regexp())
+		assert.Equal(t, `(?s)^a\.\*.c$`, middle.Regexp())
+
+		re := regexp.MustCompile(middle.Regexp())
+
+		assert.True(t, re.MatchString("a.*bc"))
+		assert.False(t, re.MatchString("abbbc"))
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		got, ok := prefix.Prefix()
+
+		assert.True(t, ok)
+		assert.Equal(t, "foo", got)
+
+		_, ok = middle.Prefix()
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,41 @@
+package searchquerylexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RegexFlags are the flags allowed after a regular expression literal
+const RegexFlags = "imsU"
+
+/*
+Regex is a regular expression literal, such as /timeout after \d+ms/i.
+Pattern is the expression between the slashes, with any \/ unescaped.
+Flags are the letters following the closing slash, and have the same
+meaning as in Go's (?flags) syntax. Regexp is the compiled expression.
+*/
+type Regex struct {
+	Pattern string
+	Flags   string
+	Regexp  *regexp.Regexp
+}
+
+/*
+Expression returns the pattern with its flags applied in Go's (?flags)
+syntax, which most regular expression engines also accept.
+*/
+func (r Regex) Expression() string {
+	if r.Flags == "" {
+		return r.Pattern
+	}
+
+	return "(?" + r.Flags + ")" + r.Pattern
+}
+
+/*
+String returns the regular expression as it would be written in a
+query.
+*/
+func (r Regex) String() string {
+	return "/" + strings.ReplaceAll(r.Pattern, "/", `\/`) + "/" + r.Flags
+}
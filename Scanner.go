@@ -0,0 +1,1087 @@
+package searchquerylexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const eof rune = -1
+
+/*
+scanner holds the state of a single pass over an input. The Lexer it
+belongs to is only ever read from, so one Lexer can hand out scanners
+to any number of goroutines.
+*/
+type scanner struct {
+	*Lexer
+
+	input string
+
+	ch              rune
+	chPos           int
+	currentPos      int
+	currentField    string
+	currentOperator Operator
+	inList          bool
+	inRange         bool
+	rangeBrackets   bool
+	rangeValues     int
+	openSubqueries  []int
+
+	currentToken *Token
+	prevToken    *Token
+	nextToken    *Token
+
+	// The line and column of byte offset linePos, see positionAt
+	linePos int
+	line    int
+	column  int
+}
+
+func (l *Lexer) newScanner(input string) *scanner {
+	return &scanner{
+		Lexer:  l,
+		input:  input,
+		line:   1,
+		column: 1,
+	}
+}
+
+func (s *scanner) next() (*Token, error) {
+	var (
+		err   error
+		token *Token
+	)
+
+	if s.currentToken != nil {
+		prevToken := *s.currentToken
+		s.prevToken = &prevToken
+	}
+
+	// A token held back by an implicit connective
+	if s.nextToken != nil {
+		s.currentToken, s.nextToken = s.nextToken, nil
+		return s.currentToken, nil
+	}
+
+	token, err = s.getNextToken()
+
+	if err == nil && s.needsImplicitConnective(token) {
+		s.nextToken = token
+		token = s.implicitConnective(token)
+	}
+
+	s.currentToken = token
+	return s.currentToken, err
+}
+
+/*
+needsImplicitConnective reports whether a condition starts right after
+another one ended, with no connective between them, such as the two
+comparisons in title="foo" age>3.
+*/
+func (s *scanner) needsImplicitConnective(token *Token) bool {
+	if s.config.ImplicitConnective == "" || s.prevToken == nil || !s.prevToken.Type.endsCondition() {
+		return false
+	}
+
+	switch token.Type {
+	case TokenTypeFieldName, TokenTypeSubqueryStart, TokenTypeNegation, TokenTypeFreeText:
+		return true
+	}
+
+	return false
+}
+
+func (s *scanner) implicitConnective(before *Token) *Token {
+	return &Token{
+		Type:      TokenTypeConnective,
+		Value:     s.config.ConnectiveConfig.word(s.config.ImplicitConnective),
+		Implicit:  true,
+		Start:     before.Start,
+		End:       before.Start,
+		Line:      before.Line,
+		Column:    before.Column,
+		EndLine:   before.Line,
+		EndColumn: before.Column,
+	}
+}
+
+func (s *scanner) getNextToken() (*Token, error) {
+	s.skipWhitespace()
+
+	start := s.currentPos
+	token, err := s.scanToken()
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return token, err
+	}
+
+	s.setPosition(token, start, s.currentPos)
+
+	// A negation has to be followed by the condition or term it negates
+	if s.prevToken != nil && s.prevToken.Type == TokenTypeNegation {
+		switch token.Type {
+		case TokenTypeFieldName, TokenTypeSubqueryStart, TokenTypeNegation, TokenTypeFreeText:
+
+		default:
+			return EmptyToken(), s.captureLinterErrorAt(s.prevToken.Start, ErrInvalidNegation)
+		}
+	}
+
+	if err != nil {
+		return token, err
+	}
+
+	switch token.Type {
+	case TokenTypeFieldName:
+		s.currentField = token.Value
+
+	case TokenTypeComparator:
+		s.currentOperator, _ = s.config.ComparatorConfig.operator(token.Value)
+
+	case TokenTypeValue:
+		if err = s.convertValue(token); err != nil {
+			return EmptyToken(), s.captureLinterErrorAt(start, err)
+		}
+	}
+
+	return token, nil
+}
+
+/*
+convertValue sets the typed value of a token that is the right hand side
+of a comparison, based on the type of the field being compared.
+*/
+func (s *scanner) convertValue(token *Token) error {
+	if !s.inList && !s.inRange && (s.prevToken == nil || s.prevToken.Type != TokenTypeComparator) {
+		return nil
+	}
+
+	// An open ended bound of a range
+	if s.inRange && s.rangeBrackets && token.isOpenBound() {
+		return nil
+	}
+
+	field, ok := s.config.Field(s.currentField)
+
+	if !ok {
+		return nil
+	}
+
+	if token.Kind == ValueKindPattern || token.Kind == ValueKindRegex {
+		if field.Type != "" && field.Type != FieldTypeString {
+			return fmt.Errorf("patterns are only allowed for string fields, not '%s': %w", field.Name, ErrInvalidValue)
+		}
+
+		return nil
+	}
+
+	operator := s.currentOperator
+
+	if operator == OperatorLike || operator == OperatorNotLike {
+		token.TypedValue = token.Value
+		return nil
+	}
+
+	typedValue, err := field.convert(token.Value)
+
+	if err != nil {
+		return err
+	}
+
+	token.TypedValue = typedValue
+	return nil
+}
+
+func (s *scanner) scanToken() (*Token, error) {
+	var (
+		err   error
+		value string
+	)
+
+	s.readChar()
+
+	if s.inList {
+		return s.scanListToken()
+	}
+
+	// A BETWEEN range is over once its upper bound has been read
+	if s.inRange && !s.rangeBrackets && s.rangeValues == 2 {
+		s.inRange = false
+	}
+
+	if s.inRange {
+		return s.scanRangeToken()
+	}
+
+	if s.ch == eof {
+		if len(s.openSubqueries) > 0 {
+			return EmptyToken(), s.captureLinterErrorAt(s.openSubqueries[len(s.openSubqueries)-1], fmt.Errorf("subquery is missing its closing ')': %w", ErrUnbalancedParentheses))
+		}
+
+		return NewToken(TokenEOF, ""), io.EOF
+	}
+
+	/*
+	 * Ranges, either [a TO b] following an EQUAL comparator, or
+	 * a AND b following a BETWEEN comparator
+	 */
+	if s.prevToken != nil && s.prevToken.Type == TokenTypeComparator {
+		if s.currentOperator == OperatorEqual && (s.ch == '[' || s.ch == '{') {
+			if err = s.checkRangeAllowed(); err != nil {
+				return EmptyToken(), s.captureLinterError(err)
+			}
+
+			s.inRange = true
+			s.rangeBrackets = true
+			s.rangeValues = 0
+
+			return NewToken(TokenTypeRangeStart, string(s.ch)), nil
+		}
+
+		if s.currentOperator == OperatorBetween {
+			s.inRange = true
+			s.rangeBrackets = false
+			s.rangeValues = 0
+
+			return s.scanRangeToken()
+		}
+	}
+
+	/*
+	 * Lists, such as the (a, b, c) following an IN comparator
+	 */
+	if s.prevToken != nil && s.prevToken.Type == TokenTypeComparator && s.currentOperator.takesList() {
+		if s.ch != '(' {
+			return EmptyToken(), s.captureLinterError(fmt.Errorf("expected '(' to start a list after '%s': %w", s.prevToken.Value, ErrInvalidList))
+		}
+
+		s.inList = true
+		return NewToken(TokenTypeListStart, "("), nil
+	}
+
+	/*
+	 * Regular expression literal
+	 */
+	if s.isRegexStart() {
+		return s.captureRegex()
+	}
+
+	/*
+	 * Quoted string
+	 */
+	if s.isStringStart() {
+		return s.scanQuotedToken(s.valueType())
+	}
+
+	/*
+	 * Subquery
+	 */
+	if s.isSubqueryStart() {
+		s.openSubqueries = append(s.openSubqueries, s.chPos)
+		return NewToken(TokenTypeSubqueryStart, "("), nil
+	}
+
+	if s.isSubqueryEnd() {
+		if len(s.openSubqueries) == 0 {
+			return EmptyToken(), s.captureLinterError(fmt.Errorf("')' has no matching '(': %w", ErrUnbalancedParentheses))
+		}
+
+		s.openSubqueries = s.openSubqueries[:len(s.openSubqueries)-1]
+		return NewToken(TokenTypeSubqueryEnd, ")"), nil
+	}
+
+	/*
+	 * Negation
+	 */
+	isNegation, negationString := s.isNegation()
+
+	if isNegation {
+		return NewToken(TokenTypeNegation, negationString), nil
+	}
+
+	/*
+	 * Connectives
+	 */
+	isConnective, connectiveString, err := s.isConnective()
+
+	if err != nil {
+		return EmptyToken(), s.captureLinterError(err)
+	}
+
+	if isConnective {
+		return NewToken(TokenTypeConnective, connectiveString), nil
+	}
+
+	/*
+	 * Comparators.
+	 *
+	 * Comparators are configurable, so what we will do is look at the first
+	 * character for each one. Then we'll peek for the length of each one
+	 * to see if it is a match.
+	 */
+	start := s.chPos
+	isComparator, comparatorString := s.isComparator()
+
+	if isComparator {
+		if err = s.checkComparatorAllowed(comparatorString); err != nil {
+			return EmptyToken(), s.captureLinterErrorAt(start, err)
+		}
+
+		return NewToken(TokenTypeComparator, comparatorString), nil
+	}
+
+	/*
+	 * If we get here, we have either a value or a field name.
+	 * To be a field name, it has to match a registered field
+	 * name, and not be preceded by a conmparator. If there are
+	 * no registered field names, then it will always be a value.
+	 */
+	isField, fieldName := s.isField()
+
+	if isField {
+		return NewToken(TokenTypeFieldName, fieldName), nil
+	}
+
+	/*
+	 * If we get here, we have a raw value.
+	 */
+	value = s.captureRawValue()
+	return s.newRawValueToken(s.valueType(), value), nil
+}
+
+/*
+newRawValueToken makes a token for an unquoted value. When wildcards
+are allowed and the value has any, the token keeps the value as written
+and carries its Pattern. Otherwise escaped wildcards are unescaped.
+*/
+func (s *scanner) newRawValueToken(tokenType TokenType, source string) *Token {
+	pattern, isPattern := parsePattern(source)
+
+	if !isPattern || !s.wildcardsAllowed(tokenType) {
+		return NewToken(tokenType, pattern.text())
+	}
+
+	result := NewToken(tokenType, source)
+	result.Kind = ValueKindPattern
+	result.TypedValue = pattern
+
+	return result
+}
+
+/*
+wildcardsAllowed reports whether a value may be a pattern. This is the
+case for free text, and for values compared for equality or likeness.
+*/
+func (s *scanner) wildcardsAllowed(tokenType TokenType) bool {
+	if tokenType == TokenTypeFreeText {
+		return true
+	}
+
+	if s.inList || s.inRange {
+		return false
+	}
+
+	switch s.currentOperator {
+	case OperatorEqual, OperatorNotEqual, OperatorLike, OperatorNotLike:
+		return true
+	}
+
+	return false
+}
+
+/*
+valueType returns TokenTypeValue for the value of a comparison, and
+TokenTypeFreeText for a value standing on its own, such as invoice in
+invoice and age > 3.
+*/
+func (s *scanner) valueType() TokenType {
+	if s.prevToken != nil && s.prevToken.Type == TokenTypeComparator {
+		return TokenTypeValue
+	}
+
+	return TokenTypeFreeText
+}
+
+/*
+scanListToken scans the inside of a list, which must be one or more
+values separated by commas and ended by a closing parenthesis.
+*/
+func (s *scanner) scanListToken() (*Token, error) {
+	expectValue := s.prevToken.Type == TokenTypeListStart || s.prevToken.Type == TokenTypeListSeparator
+
+	if s.ch == eof {
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("list is missing its closing ')': %w", ErrInvalidList))
+	}
+
+	if !expectValue {
+		switch s.ch {
+		case ',':
+			return NewToken(TokenTypeListSeparator, ","), nil
+
+		case ')':
+			s.inList = false
+			return NewToken(TokenTypeListEnd, ")"), nil
+		}
+
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("expected ',' or ')' in list: %w", ErrInvalidList))
+	}
+
+	if s.ch == ')' && s.prevToken.Type == TokenTypeListStart {
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("list cannot be empty: %w", ErrInvalidList))
+	}
+
+	if s.ch == ',' || s.ch == ')' {
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("expected a value in list: %w", ErrInvalidList))
+	}
+
+	if s.isStringStart() {
+		return s.scanQuotedToken(TokenTypeValue)
+	}
+
+	return s.newRawValueToken(TokenTypeValue, s.captureRawValue()), nil
+}
+
+/*
+scanRangeToken scans the inside of a range, which is a lower bound, a
+separator, and an upper bound. Bracketed ranges use TO as the separator
+and end with ] for an inclusive or } for an exclusive upper bound. Ranges
+following BETWEEN use the AND connective as the separator.
+*/
+func (s *scanner) scanRangeToken() (*Token, error) {
+	if s.ch == eof {
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("range is incomplete: %w", ErrInvalidRange))
+	}
+
+	// Expecting a bound
+	if s.rangeValues == 0 || (s.rangeValues == 1 && s.prevToken.Type == TokenTypeRangeSeparator) {
+		if s.isRangeEnd() || s.ch == ')' {
+			return EmptyToken(), s.captureLinterError(fmt.Errorf("expected a value in range: %w", ErrInvalidRange))
+		}
+
+		s.rangeValues++
+
+		if s.isStringStart() {
+			return s.scanQuotedToken(TokenTypeValue)
+		}
+
+		return s.newRawValueToken(TokenTypeValue, s.captureRawValue()), nil
+	}
+
+	// Expecting the separator
+	if s.rangeValues == 1 {
+		separator := RangeSeparator
+
+		if !s.rangeBrackets {
+			separator = s.config.ConnectiveConfig.And
+		}
+
+		length, ok := s.matchAt(s.chPos, separator)
+
+		if !ok || isWordChar(s.charAt(s.chPos+length)) {
+			return EmptyToken(), s.captureLinterError(fmt.Errorf("expected '%s' between the bounds of a range: %w", separator, ErrInvalidRange))
+		}
+
+		s.currentPos = s.chPos + length
+		return NewToken(TokenTypeRangeSeparator, separator), nil
+	}
+
+	// Expecting the end of a bracketed range
+	if !s.isRangeEnd() {
+		return EmptyToken(), s.captureLinterError(fmt.Errorf("expected ']' or '}' to end the range: %w", ErrInvalidRange))
+	}
+
+	s.inRange = false
+	return NewToken(TokenTypeRangeEnd, string(s.ch)), nil
+}
+
+func (s *scanner) isRangeEnd() bool {
+	return s.rangeBrackets && (s.ch == ']' || s.ch == '}')
+}
+
+/*
+checkRangeAllowed returns an error when the field being compared
+restricts its comparators and does not include BETWEEN.
+*/
+func (s *scanner) checkRangeAllowed() error {
+	field, ok := s.config.Field(s.currentField)
+
+	if ok && !field.allows(OperatorBetween) {
+		return fmt.Errorf("ranges are not allowed for field '%s': %w", field.Name, ErrComparatorNotAllowed)
+	}
+
+	return nil
+}
+
+func (s *scanner) captureRawValue() string {
+	var result strings.Builder
+
+	for s.ch != eof {
+		result.WriteRune(s.ch)
+
+		next := s.peekChar()
+
+		if next == eof || unicode.IsSpace(next) || next == ')' || (s.inList && next == ',') || (s.inRange && s.rangeBrackets && (next == ']' || next == '}')) {
+			break
+		}
+
+		s.readChar()
+	}
+
+	return result.String()
+}
+
+/*
+isRegexStart reports whether a regular expression literal starts here.
+They are only recognized as the value of a comparison for equality or
+likeness.
+*/
+func (s *scanner) isRegexStart() bool {
+	if s.ch != '/' || s.prevToken == nil || s.prevToken.Type != TokenTypeComparator {
+		return false
+	}
+
+	switch s.currentOperator {
+	case OperatorEqual, OperatorNotEqual, OperatorLike, OperatorNotLike:
+		return true
+	}
+
+	return false
+}
+
+/*
+captureRegex captures a /pattern/flags literal and compiles it. Within
+the pattern \/ is a slash, and every other escape is left for the
+regular expression itself.
+*/
+func (s *scanner) captureRegex() (*Token, error) {
+	var (
+		pattern strings.Builder
+		flags   strings.Builder
+	)
+
+	start := s.chPos
+
+	for {
+		s.readChar()
+
+		if s.ch == eof {
+			return EmptyToken(), s.captureLinterErrorAt(start, fmt.Errorf("regular expression is missing its closing '/': %w", ErrInvalidRegex))
+		}
+
+		if s.ch == '\\' && s.peekChar() == '/' {
+			s.readChar()
+			pattern.WriteRune('/')
+			continue
+		}
+
+		if s.ch == '\\' && s.peekChar() != eof {
+			pattern.WriteRune(s.ch)
+			s.readChar()
+			pattern.WriteRune(s.ch)
+			continue
+		}
+
+		if s.ch == '/' {
+			break
+		}
+
+		pattern.WriteRune(s.ch)
+	}
+
+	for isWordChar(s.peekChar()) {
+		s.readChar()
+
+		if !strings.ContainsRune(RegexFlags, s.ch) || strings.ContainsRune(flags.String(), s.ch) {
+			return EmptyToken(), s.captureLinterError(fmt.Errorf("invalid regular expression flag '%c', expected any of '%s': %w", s.ch, RegexFlags, ErrInvalidRegex))
+		}
+
+		flags.WriteRune(s.ch)
+	}
+
+	regex := Regex{
+		Pattern: pattern.String(),
+		Flags:   flags.String(),
+	}
+
+	compiled, err := regexp.Compile(regex.Expression())
+
+	if err != nil {
+		return EmptyToken(), s.captureLinterErrorAt(start, fmt.Errorf("%s: %w", err.Error(), ErrInvalidRegex))
+	}
+
+	regex.Regexp = compiled
+
+	result := NewToken(TokenTypeValue, regex.Pattern)
+	result.Kind = ValueKindRegex
+	result.TypedValue = regex
+
+	return result, nil
+}
+
+/*
+scanQuotedToken makes a token for a quoted value, marked as Quoted so
+that "30" can be told apart from 30.
+*/
+func (s *scanner) scanQuotedToken(tokenType TokenType) (*Token, error) {
+	start := s.chPos
+	value, err := s.captureQuotedValue()
+
+	if errors.Is(err, ErrUnterminatedString) {
+		return EmptyToken(), s.captureLinterErrorAt(start, err)
+	}
+
+	if err != nil {
+		return EmptyToken(), s.captureLinterError(err)
+	}
+
+	result := NewToken(tokenType, value)
+	result.Quoted = true
+
+	return result, nil
+}
+
+/*
+captureQuotedValue captures a value up to the quote character it was
+started with. Raw quotes have no escape sequences at all.
+*/
+func (s *scanner) captureQuotedValue() (string, error) {
+	var result strings.Builder
+
+	quote := s.ch
+	raw := strings.ContainsRune(s.quotes.Raw, quote)
+
+	for {
+		s.readChar()
+
+		// We have an escape sequence
+		if s.ch == '\\' && !raw {
+			s.readChar()
+
+			ch, err := s.captureEscape()
+
+			if err != nil {
+				return "", err
+			}
+
+			result.WriteRune(ch)
+			continue
+		}
+
+		if s.ch == eof {
+			return "", fmt.Errorf("string is missing its closing %c: %w", quote, ErrUnterminatedString)
+		}
+
+		// We have something to break us out
+		if s.ch == quote {
+			break
+		}
+
+		result.WriteRune(s.ch)
+	}
+
+	return result.String(), nil
+}
+
+/*
+captureEscape returns the character an escape sequence stands for, with
+ch being the character following the backslash.
+*/
+func (s *scanner) captureEscape() (rune, error) {
+	switch s.ch {
+	case 'n':
+		return '\n', nil
+
+	case 't':
+		return '\t', nil
+
+	case 'r':
+		return '\r', nil
+
+	case 'u':
+		var digits strings.Builder
+
+		for range 4 {
+			s.readChar()
+
+			if !unicode.Is(unicode.ASCII_Hex_Digit, s.ch) {
+				return 0, fmt.Errorf("\\u must be followed by four hex digits: %w", ErrInvalidEscapeSequence)
+			}
+
+			digits.WriteRune(s.ch)
+		}
+
+		code, _ := strconv.ParseUint(digits.String(), 16, 32)
+
+		if utf16.IsSurrogate(rune(code)) {
+			return 0, fmt.Errorf("\\u%s is not a valid character: %w", digits.String(), ErrInvalidEscapeSequence)
+		}
+
+		return rune(code), nil
+
+	case '\\', WildcardAny, WildcardOne:
+		return s.ch, nil
+	}
+
+	if s.ch != eof && strings.ContainsRune(s.quotes.Quotes+s.quotes.Raw, s.ch) {
+		return s.ch, nil
+	}
+
+	return 0, ErrInvalidEscapeSequence
+}
+
+/*
+readChar decodes the rune at currentPos into ch, remembering where it
+started in chPos. At the end of the input ch is eof.
+*/
+func (s *scanner) readChar() {
+	s.chPos = s.currentPos
+
+	if s.currentPos >= len(s.input) {
+		s.ch = eof
+		return
+	}
+
+	ch, size := utf8.DecodeRuneInString(s.input[s.currentPos:])
+
+	s.ch = ch
+	s.currentPos += size
+}
+
+func (s *scanner) peekChar() rune {
+	return s.charAt(s.currentPos)
+}
+
+/*
+matchAt reports whether the input at byte offset pos starts with str,
+ignoring case, and returns the number of bytes of input that matched.
+*/
+func (s *scanner) matchAt(pos int, str string) (int, bool) {
+	if str == "" {
+		return 0, false
+	}
+
+	length := 0
+
+	for _, want := range str {
+		if pos+length >= len(s.input) {
+			return 0, false
+		}
+
+		got, size := utf8.DecodeRuneInString(s.input[pos+length:])
+
+		if got != want && !strings.EqualFold(string(got), string(want)) {
+			return 0, false
+		}
+
+		length += size
+	}
+
+	return length, true
+}
+
+func (s *scanner) charAt(pos int) rune {
+	if pos >= len(s.input) {
+		return eof
+	}
+
+	ch, _ := utf8.DecodeRuneInString(s.input[pos:])
+	return ch
+}
+
+func (s *scanner) isWhitespaceAt(pos int) bool {
+	return unicode.IsSpace(s.charAt(pos))
+}
+
+func (s *scanner) skipWhitespace() {
+	for s.currentPos < len(s.input) {
+		ch, size := utf8.DecodeRuneInString(s.input[s.currentPos:])
+
+		if !unicode.IsSpace(ch) {
+			break
+		}
+
+		s.currentPos += size
+	}
+}
+
+func (s *scanner) isComparator() (bool, string) {
+	for _, comparatorString := range s.comparatorList {
+		length, ok := s.matchAt(s.chPos, comparatorString)
+
+		if !ok {
+			continue
+		}
+
+		/*
+		 * Comparators made of words, such as IN or LIKE, only follow a
+		 * field name, and cannot be the start of a longer word.
+		 */
+		if isWordLike(comparatorString) {
+			if s.prevToken == nil || s.prevToken.Type != TokenTypeFieldName || isWordChar(s.charAt(s.chPos+length)) {
+				continue
+			}
+		}
+
+		s.currentPos = s.chPos + length
+		return true, comparatorString
+	}
+
+	return false, ""
+}
+
+func isWordLike(str string) bool {
+	first, _ := utf8.DecodeRuneInString(str)
+	last, _ := utf8.DecodeLastRuneInString(str)
+
+	return isWordChar(first) || isWordChar(last)
+}
+
+func isWordChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+}
+
+func (s *scanner) isStringStart() bool {
+	return s.ch != eof && strings.ContainsRune(s.quotes.Quotes+s.quotes.Raw, s.ch)
+}
+
+func (s *scanner) isSubqueryStart() bool {
+	return s.ch == '('
+}
+
+func (s *scanner) isSubqueryEnd() bool {
+	return s.ch == ')'
+}
+
+/*
+isNegation matches the configured NOT word, or the NOT prefix when it
+is immediately followed by a field name, subquery, or free text term,
+as in -invoice. Either can only appear where a new condition may start.
+*/
+func (s *scanner) isNegation() (bool, string) {
+	if !s.atConditionStart() {
+		return false, ""
+	}
+
+	not := s.config.ConnectiveConfig.Not
+
+	if length, ok := s.matchAt(s.chPos, not); ok {
+		next := s.charAt(s.chPos + length)
+
+		if next == eof || next == '(' || next == ')' || unicode.IsSpace(next) {
+			s.currentPos = s.chPos + length
+			return true, not
+		}
+	}
+
+	prefix := s.config.ConnectiveConfig.NotPrefix
+
+	if length, ok := s.matchAt(s.chPos, prefix); ok {
+		next := s.charAt(s.chPos + length)
+
+		if next != eof && next != ')' && !unicode.IsSpace(next) {
+			s.currentPos = s.chPos + length
+			return true, prefix
+		}
+	}
+
+	return false, ""
+}
+
+func (s *scanner) atConditionStart() bool {
+	if s.prevToken == nil {
+		return true
+	}
+
+	// With implicit connectives, a new condition may follow the last one
+	if s.config.ImplicitConnective != "" && s.prevToken.Type.endsCondition() {
+		return true
+	}
+
+	// Lint may start again anywhere after an error
+	if s.prevToken.Type == TokenEmpty {
+		return true
+	}
+
+	switch s.prevToken.Type {
+	case TokenTypeConnective, TokenTypeSubqueryStart, TokenTypeNegation:
+		return true
+	}
+
+	return false
+}
+
+func (s *scanner) isConnective() (bool, string, error) {
+	for _, connectiveName := range s.connectiveList {
+		length, ok := s.matchAt(s.chPos, connectiveName)
+
+		if !ok {
+			continue
+		}
+
+		if next := s.charAt(s.chPos + length); next != eof && next != ')' && !unicode.IsSpace(next) {
+			continue
+		}
+
+		// There has to be a condition after a connective, not just the end of input or a subquery
+		rest := strings.TrimLeftFunc(s.input[s.chPos+length:], unicode.IsSpace)
+		dangling := rest == "" || rest[0] == ')'
+
+		// This can only be a connective if it is preceeded by a value, list, or subquery,
+		// or follows an error Lint has recovered from
+		if s.prevToken == nil || (!s.prevToken.Type.endsCondition() && s.prevToken.Type != TokenEmpty) {
+			// Where a condition starts, the word is searched for, unless nothing follows it
+			if dangling && s.atConditionStart() {
+				return false, "", ErrInvalidConnective
+			}
+
+			continue
+		}
+
+		if dangling {
+			return false, "", ErrInvalidConnective
+		}
+
+		s.currentPos = s.chPos + length
+		return true, connectiveName, nil
+	}
+
+	return false, "", nil
+}
+
+/*
+fieldAt returns the field whose name the input at byte offset pos starts
+with, and the number of bytes the name takes up. Like a word comparator,
+a name ending in a word character cannot be the start of a longer word,
+so a field named age does not match agenda.
+*/
+func (s *scanner) fieldAt(pos int) (FieldConfig, int, bool) {
+	for _, field := range s.fields {
+		length, ok := s.matchAt(pos, field.Name)
+
+		if !ok {
+			continue
+		}
+
+		last, _ := utf8.DecodeLastRuneInString(field.Name)
+
+		if isWordChar(last) && isWordChar(s.charAt(pos+length)) {
+			continue
+		}
+
+		return field, length, true
+	}
+
+	return FieldConfig{}, 0, false
+}
+
+func (s *scanner) isFieldAt(pos int) bool {
+	_, _, ok := s.fieldAt(pos)
+	return ok
+}
+
+func (s *scanner) isField() (bool, string) {
+	field, length, ok := s.fieldAt(s.chPos)
+
+	if !ok {
+		return false, ""
+	}
+
+	// We have a potential match. Do we have a preceeding comparator?
+	// If so this isn't a field name
+	if s.prevToken == nil || s.prevToken.Type != TokenTypeComparator {
+		s.currentPos = s.chPos + length
+		return true, field.Name
+	}
+
+	return false, ""
+}
+
+/*
+checkComparatorAllowed returns an error when the field preceding the
+comparator restricts which comparators may be used with it.
+*/
+func (s *scanner) checkComparatorAllowed(comparator string) error {
+	if s.prevToken == nil || s.prevToken.Type != TokenTypeFieldName {
+		return nil
+	}
+
+	field, ok := s.config.Field(s.prevToken.Value)
+
+	if !ok {
+		return nil
+	}
+
+	operator, _ := s.config.ComparatorConfig.operator(comparator)
+
+	if !field.allows(operator) {
+		return fmt.Errorf("comparator '%s' is not allowed for field '%s': %w", comparator, field.Name, ErrComparatorNotAllowed)
+	}
+
+	return nil
+}
+
+func (s *scanner) setPosition(token *Token, start, end int) {
+	token.Start = start
+	token.End = end
+	token.Line, token.Column = s.positionAt(start)
+	token.EndLine, token.EndColumn = s.positionAt(end)
+}
+
+/*
+positionAt returns the line and column of the byte offset pos. Tokens
+are scanned in order, so rather than counting from the start of the
+input for every token, the scanner remembers where it got to and only
+counts the input from there. An offset before that point, which only
+happens for errors, is counted from the start of the input.
+*/
+func (s *scanner) positionAt(pos int) (int, int) {
+	if pos < s.linePos {
+		return lineAndColumn(s.input, pos)
+	}
+
+	for s.linePos < pos {
+		ch, size := utf8.DecodeRuneInString(s.input[s.linePos:])
+		s.linePos += size
+
+		if ch == '\n' {
+			s.line++
+			s.column = 1
+			continue
+		}
+
+		s.column++
+	}
+
+	return s.line, s.column
+}
+
+func (s *scanner) captureLinterError(originError error) error {
+	return s.captureLinterErrorAt(s.chPos, originError)
+}
+
+/*
+captureLinterErrorAt wraps originError in a *LexError pointing at the
+byte offset pos of the input.
+*/
+func (s *scanner) captureLinterErrorAt(pos int, originError error) error {
+	pos = max(0, min(pos, len(s.input)))
+	line, column := lineAndColumn(s.input, pos)
+
+	return &LexError{
+		Input:   s.input,
+		Offset:  pos,
+		Line:    line,
+		Column:  column,
+		Err:     originError,
+		Message: s.prettyError(originError),
+	}
+}
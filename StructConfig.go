@@ -0,0 +1,196 @@
+package searchquerylexer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// StructTag is the struct tag read by ConfigFromStruct
+const StructTag = "search"
+
+/*
+ConfigFromStruct builds a Config from the search tags on the fields of
+T, which must be a struct or a pointer to one. Each tagged field becomes
+a FieldConfig, using the default comparators and connectives. Untagged
+fields are not searchable, and fields of embedded structs are read as
+well. A tag looks like this.
+
+	Title string `search:"title,column=books.title,ops=eq|like,default"`
+
+The first element is the name users type. When it is empty, the field's
+json name, or else its Go name, is used. It is followed by any of these
+options.
+
+  - column=books.title sets Column.
+  - type=int sets Type. When left out, it is worked out from the Go type
+    of the field: strings, integers, floats, bools, time.Time, and
+    time.Duration are understood.
+  - ops=eq|like sets Comparators, using Operator names.
+  - values=draft|published sets Values, for type=enum.
+  - default adds the field to DefaultFields.
+
+A tag of "-" skips the field. The result is validated the same way
+NewLexer validates a Config.
+*/
+func ConfigFromStruct[T any]() (Config, error) {
+	result := Config{
+		ComparatorConfig: DefaultComparatorConfig,
+		ConnectiveConfig: DefaultConnectiveConfig,
+	}
+
+	t := reflect.TypeFor[T]()
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return Config{}, fmt.Errorf("%s is not a struct: %w", t, ErrInvalidConfigField)
+	}
+
+	if err := result.addStructFields(t); err != nil {
+		return Config{}, err
+	}
+
+	if err := result.validate(); err != nil {
+		return Config{}, err
+	}
+
+	return result, nil
+}
+
+func (c *Config) addStructFields(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag, tagged := structField.Tag.Lookup(StructTag)
+
+		if tag == "-" {
+			continue
+		}
+
+		if !tagged {
+			embedded := structField.Type
+
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if structField.Anonymous && embedded.Kind() == reflect.Struct {
+				if err := c.addStructFields(embedded); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		field, isDefault, err := structFieldConfig(structField, tag)
+
+		if err != nil {
+			return err
+		}
+
+		c.Fields = append(c.Fields, field)
+
+		if isDefault {
+			c.DefaultFields = append(c.DefaultFields, field.Name)
+		}
+	}
+
+	return nil
+}
+
+/*
+structFieldConfig reads the search tag of a single struct field, and
+reports whether the field is one of the default fields.
+*/
+func structFieldConfig(structField reflect.StructField, tag string) (FieldConfig, bool, error) {
+	var (
+		isDefault bool
+	)
+
+	options := strings.Split(tag, ",")
+	result := FieldConfig{Name: strings.TrimSpace(options[0])}
+
+	if result.Name == "" {
+		result.Name, _, _ = strings.Cut(structField.Tag.Get("json"), ",")
+	}
+
+	if result.Name == "" || result.Name == "-" {
+		result.Name = structField.Name
+	}
+
+	for _, option := range options[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+
+		switch key {
+		case "column":
+			result.Column = value
+
+		case "type":
+			result.Type = FieldType(value)
+
+		case "ops":
+			for _, operator := range strings.Split(value, "|") {
+				result.Comparators = append(result.Comparators, Operator(operator))
+			}
+
+		case "values":
+			result.Values = strings.Split(value, "|")
+
+		case "default":
+			isDefault = true
+
+		default:
+			return FieldConfig{}, false, fmt.Errorf("field '%s' has unknown search tag option '%s': %w", structField.Name, key, ErrInvalidConfigField)
+		}
+	}
+
+	if result.Type == "" {
+		fieldType, ok := goFieldType(structField.Type)
+
+		if !ok {
+			return FieldConfig{}, false, fmt.Errorf("field '%s' needs a type option, as the type of %s cannot be worked out: %w", structField.Name, structField.Type, ErrInvalidConfigField)
+		}
+
+		result.Type = fieldType
+	}
+
+	return result, isDefault, nil
+}
+
+/*
+goFieldType works out the FieldType for values of a Go type.
+*/
+func goFieldType(t reflect.Type) (FieldType, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return FieldTypeTime, true
+
+	case reflect.TypeFor[time.Duration]():
+		return FieldTypeDuration, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FieldTypeString, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldTypeInt, true
+
+	case reflect.Float32, reflect.Float64:
+		return FieldTypeFloat, true
+
+	case reflect.Bool:
+		return FieldTypeBool, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,125 @@
+package searchquerylexer_test
+
+import (
+	"testing"
+	"time"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+type timestamps struct {
+	Created time.Time `search:"created"`
+}
+
+type document struct {
+	*timestamps
+
+	ID       int64         `json:"id" search:""`
+	Title    string        `search:"title,column=documents.title_text,ops=eq|like|notlike,default"`
+	Body     string        `json:"body,omitempty" search:",default"`
+	Rating   *float32      `search:"rating"`
+	Age      time.Duration `search:"age"`
+	Archived bool          `search:"archived,ops=eq"`
+	Status   string        `search:"status,type=enum,values=draft|published"`
+	OwnerID  [16]byte      `search:"owner,type=uuid"`
+	Secret   string        `search:"-"`
+	Notes    string
+	Extra    map[string]any `json:"extra"`
+}
+
+func TestConfigFromStruct(t *testing.T) {
+	t.Run("fields are read from tags", func(t *testing.T) {
+		want := []sql.FieldConfig{
+			{Name: "created", Type: sql.FieldTypeTime},
+			{Name: "id", Type: sql.FieldTypeInt},
+			{
+				Name:        "title",
+				Column:      "documents.title_text",
+				Comparators: []sql.Operator{sql.OperatorEqual, sql.OperatorLike, sql.OperatorNotLike},
+				Type:        sql.FieldTypeString,
+			},
+			{Name: "body", Type: sql.FieldTypeString},
+			{Name: "rating", Type: sql.FieldTypeFloat},
+			{Name: "age", Type: sql.FieldTypeDuration},
+			{Name: "archived", Comparators: []sql.Operator{sql.OperatorEqual}, Type: sql.FieldTypeBool},
+			{Name: "status", Type: sql.FieldTypeEnum, Values: []string{"draft", "published"}},
+			{Name: "owner", Type: sql.FieldTypeUUID},
+		}
+
+		config, err := sql.ConfigFromStruct[document]()
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, config.Fields)
+		assert.Equal(t, []string{"title", "body"}, config.DefaultFields)
+		assert.Equal(t, sql.DefaultComparatorConfig, config.ComparatorConfig)
+		assert.Equal(t, sql.DefaultConnectiveConfig, config.ConnectiveConfig)
+
+		pointerConfig, err := sql.ConfigFromStruct[*document]()
+
+		assert.NoError(t, err)
+		assert.Equal(t, config, pointerConfig)
+	})
+
+	t.Run("the config can be used to lex", func(t *testing.T) {
+		config, err := sql.ConfigFromStruct[document]()
+		assert.NoError(t, err)
+
+		lexer, err := sql.NewLexer(config)
+		assert.NoError(t, err)
+
+		tokens, err := lexer.Tokenize(`id > 3 and status = draft and archived = true`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), tokens[2].TypedValue)
+
+		_, err = lexer.Tokenize(`archived != true`)
+		assert.ErrorIs(t, err, sql.ErrComparatorNotAllowed)
+
+		tokens, err = lexer.Tokenize(`notes`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sql.TokenTypeFreeText, tokens[0].Type)
+	})
+
+	t.Run("invalid tags", func(t *testing.T) {
+		type unknownOption struct {
+			Title string `search:"title,sortable"`
+		}
+
+		type unknownType struct {
+			Extra map[string]any `search:"extra"`
+		}
+
+		type unknownOperator struct {
+			Title string `search:"title,ops=eq|contains"`
+		}
+
+		type enumWithoutValues struct {
+			Status string `search:"status,type=enum"`
+		}
+
+		type duplicateName struct {
+			Title string `search:"title"`
+			Name  string `search:"Title"`
+		}
+
+		_, err := sql.ConfigFromStruct[unknownOption]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		_, err = sql.ConfigFromStruct[unknownType]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		_, err = sql.ConfigFromStruct[unknownOperator]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		_, err = sql.ConfigFromStruct[enumWithoutValues]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		_, err = sql.ConfigFromStruct[duplicateName]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+
+		_, err = sql.ConfigFromStruct[string]()
+		assert.ErrorIs(t, err, sql.ErrInvalidConfigField)
+	})
+}
@@ -2,9 +2,39 @@ package searchquerylexer
 
 import "fmt"
 
+/*
+Token is a single lexeme of a search query. Start and End are byte
+offsets into the input, with End being exclusive. Line and Column are
+1-based, and Column counts runes rather than bytes. EndLine and
+EndColumn are the position of End.
+
+TypedValue is set on values compared against a field, and holds the
+value converted to the Go type matching the field's FieldType.
+
+Kind is ValueKindPattern for values containing wildcards, such as foo*,
+ValueKindRegex for regular expression literals, such as /ab+c/i, and
+empty for everything else.
+
+Quoted is set on values that were quoted, so "30" can be told apart
+from 30.
+
+Implicit is set on connectives that were not in the input, but were
+inserted between adjacent conditions because Config.ImplicitConnective
+is set. They are zero length, positioned at the following token.
+*/
 type Token struct {
-	Type  TokenType
-	Value string
+	Type       TokenType
+	Value      string
+	TypedValue any
+	Kind       ValueKind
+	Quoted     bool
+	Implicit   bool
+	Start      int
+	End        int
+	Line       int
+	Column     int
+	EndLine    int
+	EndColumn  int
 }
 
 func NewToken(tokenType TokenType, value string) *Token {
@@ -18,6 +48,49 @@ func EmptyToken() *Token {
 	return &Token{Type: TokenEmpty, Value: ""}
 }
 
+/*
+isOpenBound reports whether the token is an unquoted RangeOpenBound. A
+quoted "*" is a literal value.
+*/
+func (t *Token) isOpenBound() bool {
+	return t.Type == TokenTypeValue && !t.Quoted && t.Value == RangeOpenBound
+}
+
+/*
+Typed returns the token's TypedValue, or its Value when it has no
+TypedValue, such as free text or values of an untyped field.
+*/
+func (t *Token) Typed() any {
+	if t.TypedValue != nil {
+		return t.TypedValue
+	}
+
+	return t.Value
+}
+
 func (t *Token) String() string {
 	return fmt.Sprintf("%s: '%s'", t.Type, t.Value)
 }
+
+/*
+TypedValues returns the Typed value of each of tokens, such as the
+values of an IN list.
+*/
+func TypedValues(tokens []*Token) []any {
+	result := make([]any, 0, len(tokens))
+
+	for _, token := range tokens {
+		result = append(result, token.Typed())
+	}
+
+	return result
+}
+
+type ValueKind string
+
+const (
+	// ValueKindPattern marks a value containing wildcards. Its TypedValue is a Pattern
+	ValueKindPattern ValueKind = "pattern"
+	// ValueKindRegex marks a regular expression literal. Its TypedValue is a Regex
+	ValueKindRegex ValueKind = "regex"
+)
@@ -0,0 +1,86 @@
+package searchquerylexer
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+/*
+TokenStream hands out tokens one at a time as the input is scanned,
+rather than building the whole slice up front like Tokenize does.
+*/
+type TokenStream struct {
+	scanner *scanner
+	err     error
+}
+
+/*
+Stream starts scanning input. Tokens are read with Next. Each stream
+keeps its own position, so any number of streams may be read at once.
+*/
+func (l *Lexer) Stream(input string) *TokenStream {
+	return &TokenStream{
+		scanner: l.newScanner(input),
+	}
+}
+
+/*
+Next returns the next token. When the input is exhausted it returns an
+EOF token and io.EOF. Once an error is returned, every later call
+returns the same error.
+*/
+func (s *TokenStream) Next() (*Token, error) {
+	if s.err != nil {
+		if errors.Is(s.err, io.EOF) {
+			return s.scanner.currentToken, s.err
+		}
+
+		return EmptyToken(), s.err
+	}
+
+	token, err := s.scanner.next()
+	s.err = err
+
+	return token, err
+}
+
+/*
+All returns an iterator over the remaining tokens of the stream. It
+stops at the end of the input, or after yielding the first error.
+*/
+func (s *TokenStream) All() iter.Seq2[*Token, error] {
+	return func(yield func(*Token, error) bool) {
+		for {
+			token, err := s.Next()
+
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(token, nil) {
+				return
+			}
+		}
+	}
+}
+
+/*
+Tokens returns an iterator over the tokens of input. It is shorthand
+for Stream(input).All().
+
+	for token, err := range lexer.Tokens(input) {
+		if err != nil {
+			return err
+		}
+		...
+	}
+*/
+func (l *Lexer) Tokens(input string) iter.Seq2[*Token, error] {
+	return l.Stream(input).All()
+}
@@ -0,0 +1,103 @@
+package searchquerylexer_test
+
+import (
+	"io"
+	"testing"
+
+	sql "github.com/adampresley/search-query-lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenStream(t *testing.T) {
+	config := sql.Config{
+		ComparatorConfig: sql.DefaultComparatorConfig,
+		ConnectiveConfig: sql.DefaultConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"age",
+		},
+	}
+
+	lexer, err := sql.NewLexer(config)
+	assert.NoError(t, err)
+
+	t.Run("next", func(t *testing.T) {
+		stream := lexer.Stream("title = test")
+
+		want := []*sql.Token{
+			sql.NewToken(sql.TokenTypeFieldName, "title"),
+			sql.NewToken(sql.TokenTypeComparator, "="),
+			sql.NewToken(sql.TokenTypeValue, "test"),
+		}
+
+		for _, w := range want {
+			got, err := stream.Next()
+
+			assert.NoError(t, err)
+			assert.Equal(t, w.Type, got.Type)
+			assert.Equal(t, w.Value, got.Value)
+		}
+
+		for range 2 {
+			got, err := stream.Next()
+
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, sql.TokenEOF, got.Type)
+		}
+	})
+
+	t.Run("next stops at the first error", func(t *testing.T) {
+		stream := lexer.Stream(`title="\atest" and age > 3`)
+
+		_, err := stream.Next()
+		assert.NoError(t, err)
+
+		_, err = stream.Next()
+		assert.NoError(t, err)
+
+		_, err = stream.Next()
+		assert.ErrorIs(t, err, sql.ErrInvalidEscapeSequence)
+
+		_, err = stream.Next()
+		assert.ErrorIs(t, err, sql.ErrInvalidEscapeSequence)
+	})
+
+	t.Run("range over tokens", func(t *testing.T) {
+		got := []*sql.Token{}
+
+		for token, err := range lexer.Tokens("title = test or age > 3") {
+			assert.NoError(t, err)
+			got = append(got, token)
+		}
+
+		assert.Len(t, got, 7)
+	})
+
+	t.Run("range can stop early", func(t *testing.T) {
+		count := 0
+
+		for token, err := range lexer.Tokens("title = test or age > 3") {
+			assert.NoError(t, err)
+			count++
+
+			if token.Type == sql.TokenTypeConnective {
+				break
+			}
+		}
+
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("range yields the first error", func(t *testing.T) {
+		var errs []error
+
+		for _, err := range lexer.Tokens(`title="\atest" and age > 3`) {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		assert.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], sql.ErrInvalidEscapeSequence)
+	})
+}
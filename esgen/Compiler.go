@@ -0,0 +1,395 @@
+/*
+Package esgen compiles search queries into Elasticsearch and OpenSearch
+Query DSL. Queries are plain map[string]any values, ready to be encoded
+with encoding/json and placed under the "query" key of a search request.
+*/
+package esgen
+
+import (
+	"fmt"
+	"strings"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+/*
+Options changes how queries are generated. CaseInsensitive sets
+case_insensitive on term, wildcard, and regexp queries against string
+values.
+*/
+type Options struct {
+	CaseInsensitive bool
+}
+
+type Compiler struct {
+	config  searchquerylexer.Config
+	parser  *searchquerylexer.Parser
+	options Options
+}
+
+func NewCompiler(config searchquerylexer.Config, options Options) (*Compiler, error) {
+	parser, err := searchquerylexer.NewParser(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Compiler{
+		config:  config,
+		parser:  parser,
+		options: options,
+	}
+
+	return result, nil
+}
+
+/*
+Compile parses input and returns a query.
+*/
+func (c *Compiler) Compile(input string) (map[string]any, error) {
+	node, err := c.parser.Parse(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileTokens returns the query for tokens that were already lexed,
+such as those kept from an earlier call to Lexer.Tokenize.
+*/
+func (c *Compiler) CompileTokens(tokens []*searchquerylexer.Token) (map[string]any, error) {
+	node, err := c.parser.ParseTokens(tokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileNode turns a parsed tree into a query. Field names are replaced
+with the index field configured as their Column, such as title.keyword.
+A field that is not configured fails with ErrUnknownField.
+*/
+func CompileNode(node searchquerylexer.Node, config searchquerylexer.Config, options Options) (map[string]any, error) {
+	b := &builder{
+		config:  config,
+		options: options,
+	}
+
+	return b.query(node)
+}
+
+type builder struct {
+	config  searchquerylexer.Config
+	options Options
+}
+
+func (b *builder) query(node searchquerylexer.Node) (map[string]any, error) {
+	switch n := node.(type) {
+	case *searchquerylexer.BinaryExpr:
+		return b.binary(n)
+
+	case *searchquerylexer.Group:
+		return b.query(n.Expr)
+
+	case *searchquerylexer.NotExpr:
+		query, err := b.query(n.Expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return mustNot(query), nil
+
+	case *searchquerylexer.Comparison:
+		return b.comparison(n)
+
+	case *searchquerylexer.Range:
+		return b.rangeQuery(n)
+
+	case *searchquerylexer.FreeText:
+		return b.freeText(n)
+	}
+
+	return nil, fmt.Errorf("%T: %w", node, searchquerylexer.ErrUnsupportedNode)
+}
+
+/*
+binary turns a chain of the same connective, such as a and b and c,
+into a single bool query. AND becomes must, and OR becomes should with
+a minimum_should_match of 1. Subqueries become nested bool queries.
+*/
+func (b *builder) binary(n *searchquerylexer.BinaryExpr) (map[string]any, error) {
+	clauses := []any{}
+
+	for _, child := range []searchquerylexer.Node{n.Left, n.Right} {
+		query, err := b.query(child)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nested, ok := child.(*searchquerylexer.BinaryExpr)
+
+		if ok && nested.Connective == n.Connective {
+			clauses = append(clauses, boolClauses(query, n.Connective)...)
+			continue
+		}
+
+		clauses = append(clauses, query)
+	}
+
+	if n.Connective == searchquerylexer.ConnectiveOr {
+		return should(clauses), nil
+	}
+
+	return boolQuery(map[string]any{"must": clauses}), nil
+}
+
+func (b *builder) comparison(n *searchquerylexer.Comparison) (map[string]any, error) {
+	field, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorIn:
+		return map[string]any{"terms": map[string]any{field: searchquerylexer.TypedValues(n.Values)}}, nil
+
+	case searchquerylexer.OperatorNotIn:
+		return mustNot(map[string]any{"terms": map[string]any{field: searchquerylexer.TypedValues(n.Values)}}), nil
+	}
+
+	var (
+		query map[string]any
+	)
+
+	switch value := n.Value.TypedValue.(type) {
+	case searchquerylexer.Pattern:
+		query = b.caseInsensitive("wildcard", field, wildcard(value, n.Operator == searchquerylexer.OperatorLike || n.Operator == searchquerylexer.OperatorNotLike))
+		return b.negate(n, query)
+
+	case searchquerylexer.Regex:
+		pattern, err := luceneRegexp(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		query = map[string]any{"regexp": map[string]any{field: map[string]any{"value": pattern}}}
+
+		if strings.ContainsRune(value.Flags, 'i') {
+			query["regexp"].(map[string]any)[field].(map[string]any)["case_insensitive"] = true
+		}
+
+		return b.negate(n, query)
+	}
+
+	value := n.Value.Typed()
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual:
+		return b.caseInsensitive("term", field, value), nil
+
+	case searchquerylexer.OperatorNotEqual:
+		return mustNot(b.caseInsensitive("term", field, value)), nil
+
+	case searchquerylexer.OperatorLessThan:
+		return rangeOf(field, map[string]any{"lt": value}), nil
+
+	case searchquerylexer.OperatorGreaterThan:
+		return rangeOf(field, map[string]any{"gt": value}), nil
+
+	case searchquerylexer.OperatorLessThanEqualTo:
+		return rangeOf(field, map[string]any{"lte": value}), nil
+
+	case searchquerylexer.OperatorGreaterThanEqualTo:
+		return rangeOf(field, map[string]any{"gte": value}), nil
+
+	case searchquerylexer.OperatorLike:
+		return match(field, n.Value.Value), nil
+
+	case searchquerylexer.OperatorNotLike:
+		return mustNot(match(field, n.Value.Value)), nil
+	}
+
+	return nil, fmt.Errorf("'%s': %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+/*
+negate wraps a wildcard or regexp query in must_not for the NOT EQUAL
+and NOT LIKE comparators.
+*/
+func (b *builder) negate(n *searchquerylexer.Comparison, query map[string]any) (map[string]any, error) {
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual, searchquerylexer.OperatorLike:
+		return query, nil
+
+	case searchquerylexer.OperatorNotEqual, searchquerylexer.OperatorNotLike:
+		return mustNot(query), nil
+	}
+
+	return nil, fmt.Errorf("'%s' with a pattern: %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+func (b *builder) rangeQuery(n *searchquerylexer.Range) (map[string]any, error) {
+	field, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := map[string]any{}
+
+	if n.Lower != nil {
+		operator := "gt"
+
+		if n.IncludeLower {
+			operator = "gte"
+		}
+
+		bounds[operator] = n.Lower.Typed()
+	}
+
+	if n.Upper != nil {
+		operator := "lt"
+
+		if n.IncludeUpper {
+			operator = "lte"
+		}
+
+		bounds[operator] = n.Upper.Typed()
+	}
+
+	// Both bounds open only requires a value
+	if len(bounds) == 0 {
+		return map[string]any{"exists": map[string]any{"field": field}}, nil
+	}
+
+	return rangeOf(field, bounds), nil
+}
+
+/*
+freeText turns the term into a multi_match query over the free text
+fields. A term with wildcards becomes a wildcard query per field
+instead, matching any of them.
+*/
+func (b *builder) freeText(n *searchquerylexer.FreeText) (map[string]any, error) {
+	fields := b.config.FreeTextFields()
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("'%s': %w", n.Value.Value, searchquerylexer.ErrNoDefaultFields)
+	}
+
+	pattern, ok := n.Value.TypedValue.(searchquerylexer.Pattern)
+
+	if !ok {
+		names := make([]any, 0, len(fields))
+
+		for _, field := range fields {
+			names = append(names, field.ColumnName())
+		}
+
+		return map[string]any{"multi_match": map[string]any{"query": n.Value.Value, "fields": names}}, nil
+	}
+
+	clauses := make([]any, 0, len(fields))
+
+	for _, field := range fields {
+		clauses = append(clauses, b.caseInsensitive("wildcard", field.ColumnName(), wildcard(pattern, false)))
+	}
+
+	if len(clauses) == 1 {
+		return clauses[0].(map[string]any), nil
+	}
+
+	return should(clauses), nil
+}
+
+/*
+caseInsensitive builds a term or wildcard query, setting
+case_insensitive when the options ask for it and the value is a string.
+*/
+func (b *builder) caseInsensitive(kind, field string, value any) map[string]any {
+	body := map[string]any{"value": value}
+
+	if _, ok := value.(string); ok && b.options.CaseInsensitive {
+		body["case_insensitive"] = true
+	}
+
+	return map[string]any{kind: map[string]any{field: body}}
+}
+
+/*
+wildcard writes a pattern in the wildcard query syntax, which already
+uses * and ?. Wildcard queries match the whole value, so unanchored
+patterns, as with LIKE, are surrounded by *.
+*/
+func wildcard(pattern searchquerylexer.Pattern, unanchored bool) string {
+	var result strings.Builder
+
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"*", `\*`,
+		"?", `\?`,
+	)
+
+	if unanchored {
+		result.WriteRune(searchquerylexer.WildcardAny)
+	}
+
+	for _, part := range pattern {
+		if part.Wildcard != 0 {
+			result.WriteRune(part.Wildcard)
+			continue
+		}
+
+		result.WriteString(replacer.Replace(part.Literal))
+	}
+
+	if unanchored {
+		result.WriteRune(searchquerylexer.WildcardAny)
+	}
+
+	return result.String()
+}
+
+func match(field, value string) map[string]any {
+	return map[string]any{"match": map[string]any{field: map[string]any{"query": value, "operator": "and"}}}
+}
+
+func rangeOf(field string, bounds map[string]any) map[string]any {
+	return map[string]any{"range": map[string]any{field: bounds}}
+}
+
+func boolQuery(clauses map[string]any) map[string]any {
+	return map[string]any{"bool": clauses}
+}
+
+func should(clauses []any) map[string]any {
+	return boolQuery(map[string]any{"should": clauses, "minimum_should_match": 1})
+}
+
+func mustNot(query map[string]any) map[string]any {
+	return boolQuery(map[string]any{"must_not": []any{query}})
+}
+
+/*
+boolClauses returns the clauses of a bool query built by binary for
+connective.
+*/
+func boolClauses(query map[string]any, connective searchquerylexer.Connective) []any {
+	key := "must"
+
+	if connective == searchquerylexer.ConnectiveOr {
+		key = "should"
+	}
+
+	return query["bool"].(map[string]any)[key].([]any)
+}
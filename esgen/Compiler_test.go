@@ -0,0 +1,208 @@
+package esgen_test
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+	"github.com/adampresley/search-query-lexer/esgen"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testConfig() searchquerylexer.Config {
+	return searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"category",
+		},
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "title", Column: "title.keyword"},
+			{Name: "body"},
+			{Name: "age", Type: searchquerylexer.FieldTypeInt},
+		},
+		DefaultFields: []string{"title", "body"},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	table := []struct {
+		golden  string
+		input   string
+		options esgen.Options
+	}{
+		{golden: "term", input: `title = "Go in Action"`},
+		{golden: "comparisons", input: `age > 1 and age <= 30 and category != news`},
+		{golden: "subqueries", input: `(title=~"test" AND age >= 30) OR (category != "bad")`},
+		{golden: "negation", input: `not (category = a or category = b) and body !~ spam`},
+		{golden: "lists", input: `age in (1, 2, 3) or category not in (x, y)`},
+		{golden: "ranges", input: `age between 18 and 30 and age = {1 to *] and age = [* TO *]`},
+		{golden: "patterns", input: `title = "Go *" and body =~ j?n and category != a\*b*`},
+		{golden: "case_insensitive", input: `title = Go and body = foo* and age = 3`, options: esgen.Options{CaseInsensitive: true}},
+		{golden: "regex", input: `body =~ /time(out)?/i and category !~ /n.*s/`},
+		{golden: "free_text", input: `invoice or inv*`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.golden, func(t *testing.T) {
+			compiler, err := esgen.NewCompiler(testConfig(), tt.options)
+			assert.NoError(t, err)
+
+			query, err := compiler.Compile(tt.input)
+			assert.NoError(t, err)
+
+			got, err := json.MarshalIndent(query, "", "  ")
+			assert.NoError(t, err)
+
+			got = append(got, '\n')
+			path := filepath.Join("testdata", tt.golden+".json")
+
+			if *update {
+				assert.NoError(t, os.WriteFile(path, got, 0o644))
+			}
+
+			want, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestCompileRegex(t *testing.T) {
+	table := []struct {
+		input string
+		want  string
+	}{
+		{input: `/time(out)?/`, want: `.*time(out)?.*`},
+		{input: `/^time(out)?$/`, want: `time(out)?`},
+		{input: `/^a|b$/`, want: `(a.*)|(.*b)`},
+		{input: `/\d{2,}-x+/`, want: `.*[0-9]{2,}\-x+.*`},
+		{input: `/(?i)go/s`, want: `.*[Gg][Oo].*`},
+		{input: `/a.b/`, want: ".*a[^\n]b.*"},
+		{input: `/a@b~c/`, want: `.*a\@b\~c.*`},
+	}
+
+	compiler, err := esgen.NewCompiler(testConfig(), esgen.Options{})
+	assert.NoError(t, err)
+
+	for _, tt := range table {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := compiler.Compile(`body =~ ` + tt.input)
+			assert.NoError(t, err)
+
+			want := map[string]any{
+				"regexp": map[string]any{
+					"body": map[string]any{"value": tt.want},
+				},
+			}
+
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	table := []struct {
+		name        string
+		config      searchquerylexer.Config
+		input       string
+		expectedErr error
+	}{
+		{
+			name:        "regular expression Lucene cannot express",
+			config:      testConfig(),
+			input:       `body =~ /\bword\b/`,
+			expectedErr: searchquerylexer.ErrUnsupportedOperator,
+		},
+		{
+			name: "free text without default fields",
+			config: searchquerylexer.Config{
+				ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+				ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+				FieldNames:       []string{"title"},
+			},
+			input:       `invoice`,
+			expectedErr: searchquerylexer.ErrNoDefaultFields,
+		},
+		{
+			name:        "parse errors are returned",
+			config:      testConfig(),
+			input:       `(title = a`,
+			expectedErr: searchquerylexer.ErrUnbalancedParentheses,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler, err := esgen.NewCompiler(tt.config, esgen.Options{})
+			assert.NoError(t, err)
+
+			_, err = compiler.Compile(tt.input)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestCompileTokens(t *testing.T) {
+	config := testConfig()
+
+	lexer, err := searchquerylexer.NewLexer(config)
+	assert.NoError(t, err)
+
+	compiler, err := esgen.NewCompiler(config, esgen.Options{})
+	assert.NoError(t, err)
+
+	tokens, err := lexer.Tokenize(`category = a`)
+	assert.NoError(t, err)
+
+	got, err := compiler.CompileTokens(tokens)
+
+	want := map[string]any{
+		"term": map[string]any{
+			"category": map[string]any{"value": "a"},
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestCompileUnknownField(t *testing.T) {
+	field := searchquerylexer.NewToken(searchquerylexer.TokenTypeFieldName, "_id")
+
+	table := []struct {
+		name string
+		node searchquerylexer.Node
+	}{
+		{
+			name: "comparison",
+			node: &searchquerylexer.Comparison{
+				Field:      field,
+				Comparator: searchquerylexer.NewToken(searchquerylexer.TokenTypeComparator, "="),
+				Operator:   searchquerylexer.OperatorEqual,
+				Value:      searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+			},
+		},
+		{
+			name: "range",
+			node: &searchquerylexer.Range{
+				Field: field,
+				Lower: searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+			},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := esgen.CompileNode(tt.node, testConfig(), esgen.Options{})
+
+			assert.ErrorIs(t, err, searchquerylexer.ErrUnknownField)
+			assert.Nil(t, got)
+		})
+	}
+}
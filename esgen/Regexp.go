@@ -0,0 +1,257 @@
+package esgen
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+/*
+luceneRegexp translates a Go regular expression into Lucene's syntax.
+Lucene matches the whole term, while Go matches anywhere within the
+value, so ends that are not anchored with ^ or $ are padded with .*.
+Shorthand classes such as \d become explicit ranges, and (?i) within the
+pattern becomes a class per letter. Constructs Lucene has no equivalent
+for, such as \b or anchors within the pattern, fail with
+ErrUnsupportedOperator. The i flag is left to the case_insensitive
+option of the query.
+*/
+func luceneRegexp(regex searchquerylexer.Regex) (string, error) {
+	flags := syntax.Perl
+
+	if strings.ContainsRune(regex.Flags, 's') {
+		flags |= syntax.DotNL
+	}
+
+	if !strings.ContainsRune(regex.Flags, 'm') {
+		flags |= syntax.OneLine
+	}
+
+	parsed, err := syntax.Parse(regex.Pattern, flags)
+
+	if err != nil {
+		return "", fmt.Errorf("regular expression '%s': %w", regex.Pattern, searchquerylexer.ErrInvalidRegex)
+	}
+
+	var result strings.Builder
+
+	if err := writeAnchored(&result, parsed); err != nil {
+		return "", fmt.Errorf("regular expression '%s': %w", regex.Pattern, err)
+	}
+
+	return result.String(), nil
+}
+
+/*
+writeAnchored writes re so that it matches a whole term. Each branch of
+an alternation is anchored on its own, as in ^a|b.
+*/
+func writeAnchored(result *strings.Builder, re *syntax.Regexp) error {
+	if re.Op == syntax.OpAlternate {
+		for i, sub := range re.Sub {
+			if i > 0 {
+				result.WriteString("|")
+			}
+
+			result.WriteString("(")
+
+			if err := writeAnchored(result, sub); err != nil {
+				return err
+			}
+
+			result.WriteString(")")
+		}
+
+		return nil
+	}
+
+	subs := []*syntax.Regexp{re}
+
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		subs = subs[1:]
+	} else {
+		result.WriteString(".*")
+	}
+
+	anchoredEnd := len(subs) > 0 && subs[len(subs)-1].Op == syntax.OpEndText
+
+	if anchoredEnd {
+		subs = subs[:len(subs)-1]
+	}
+
+	for _, sub := range subs {
+		if err := writeLucene(result, sub); err != nil {
+			return err
+		}
+	}
+
+	if !anchoredEnd {
+		result.WriteString(".*")
+	}
+
+	return nil
+}
+
+func writeLucene(result *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		result.WriteString("()")
+
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && unicode.SimpleFold(r) != r {
+				writeFold(result, r)
+				continue
+			}
+
+			writeRune(result, r)
+		}
+
+	case syntax.OpCharClass:
+		writeClass(result, re.Rune)
+
+	case syntax.OpAnyChar:
+		result.WriteString(".")
+
+	case syntax.OpAnyCharNotNL:
+		result.WriteString("[^\n]")
+
+	case syntax.OpCapture:
+		result.WriteString("(")
+
+		if err := writeLucene(result, re.Sub[0]); err != nil {
+			return err
+		}
+
+		result.WriteString(")")
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if err := writeAtom(result, re.Sub[0]); err != nil {
+			return err
+		}
+
+		writeRepeat(result, re)
+
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := writeLucene(result, sub); err != nil {
+				return err
+			}
+		}
+
+	case syntax.OpAlternate:
+		result.WriteString("(")
+
+		for i, sub := range re.Sub {
+			if i > 0 {
+				result.WriteString("|")
+			}
+
+			if err := writeLucene(result, sub); err != nil {
+				return err
+			}
+		}
+
+		result.WriteString(")")
+
+	default:
+		return fmt.Errorf("'%s' cannot be expressed in a regexp query: %w", re, searchquerylexer.ErrUnsupportedOperator)
+	}
+
+	return nil
+}
+
+/*
+writeAtom writes the operand of a repetition, which needs parentheses
+unless it is a single character or already a group.
+*/
+func writeAtom(result *strings.Builder, re *syntax.Regexp) error {
+	single := re.Op == syntax.OpCharClass || re.Op == syntax.OpAnyChar || re.Op == syntax.OpAnyCharNotNL || re.Op == syntax.OpCapture ||
+		(re.Op == syntax.OpLiteral && len(re.Rune) == 1)
+
+	if single {
+		return writeLucene(result, re)
+	}
+
+	result.WriteString("(")
+
+	if err := writeLucene(result, re); err != nil {
+		return err
+	}
+
+	result.WriteString(")")
+	return nil
+}
+
+func writeRepeat(result *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpStar:
+		result.WriteString("*")
+
+	case syntax.OpPlus:
+		result.WriteString("+")
+
+	case syntax.OpQuest:
+		result.WriteString("?")
+
+	case syntax.OpRepeat:
+		result.WriteString("{" + strconv.Itoa(re.Min) + ",")
+
+		if re.Max >= 0 {
+			result.WriteString(strconv.Itoa(re.Max))
+		}
+
+		result.WriteString("}")
+	}
+}
+
+func writeFold(result *strings.Builder, r rune) {
+	result.WriteString("[")
+	writeRune(result, r)
+
+	for fold := unicode.SimpleFold(r); fold != r; fold = unicode.SimpleFold(fold) {
+		writeRune(result, fold)
+	}
+
+	result.WriteString("]")
+}
+
+/*
+writeClass writes the ranges of a character class, which come in pairs
+of the lowest and highest rune.
+*/
+func writeClass(result *strings.Builder, ranges []rune) {
+	result.WriteString("[")
+
+	for i := 0; i < len(ranges); i += 2 {
+		writeRune(result, ranges[i])
+
+		if ranges[i+1] != ranges[i] {
+			result.WriteString("-")
+			writeRune(result, ranges[i+1])
+		}
+	}
+
+	result.WriteString("]")
+}
+
+/*
+writeRune writes r, escaping ASCII punctuation, which covers every
+character Lucene reserves, such as @, &, and ~.
+*/
+func writeRune(result *strings.Builder, r rune) {
+	if r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+		result.WriteString(`\`)
+	}
+
+	result.WriteRune(r)
+}
@@ -0,0 +1,370 @@
+/*
+Package evaluator compiles search queries into predicates that filter
+data already in memory, such as cached records or API responses. Fields
+are resolved against maps with string keys and against struct fields.
+*/
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+/*
+Predicate reports whether a value, usually a map[string]any or a
+struct, matches the query it was compiled from.
+*/
+type Predicate func(value any) bool
+
+/*
+Options changes how fields are resolved. Tag is the struct tag whose
+name, as in `json:"title"`, a field is known by, and defaults to json.
+Struct fields without the tag are known by their Go name.
+
+ColumnPaths resolves each field by its Column rather than its Name.
+Leave it off when the Config is shared with sqlgen, whose columns only
+mean something to the database.
+*/
+type Options struct {
+	Tag         string
+	ColumnPaths bool
+}
+
+type Compiler struct {
+	config  searchquerylexer.Config
+	parser  *searchquerylexer.Parser
+	options Options
+}
+
+func NewCompiler(config searchquerylexer.Config, options Options) (*Compiler, error) {
+	parser, err := searchquerylexer.NewParser(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Compiler{
+		config:  config,
+		parser:  parser,
+		options: options,
+	}
+
+	return result, nil
+}
+
+/*
+Compile parses input and returns a predicate.
+*/
+func (c *Compiler) Compile(input string) (Predicate, error) {
+	node, err := c.parser.Parse(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileTokens returns a predicate for tokens from Lexer.Tokenize, so
+input that was already lexed, for example to Lint it, is not scanned
+again.
+*/
+func (c *Compiler) CompileTokens(tokens []*searchquerylexer.Token) (Predicate, error) {
+	node, err := c.parser.ParseTokens(tokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileNode turns a parsed tree into a predicate. Fields are resolved
+by their Name, or their Column when options.ColumnPaths is set, where a
+dotted path such as author.name walks into nested maps and structs. A
+field that is not configured fails with ErrUnknownField.
+
+Values are compared by type. Numbers compare numerically, times
+chronologically, and strings exactly. LIKE is a case-insensitive
+substring match. When the resolved value is a slice, a comparison
+matches if any element does. A field that cannot be resolved matches no
+comparison, so its negations, such as NOT EQUAL, match.
+*/
+func CompileNode(node searchquerylexer.Node, config searchquerylexer.Config, options Options) (Predicate, error) {
+	if options.Tag == "" {
+		options.Tag = "json"
+	}
+
+	b := &builder{
+		config:  config,
+		options: options,
+	}
+
+	return b.predicate(node)
+}
+
+type builder struct {
+	config  searchquerylexer.Config
+	options Options
+}
+
+/*
+matcher tests a single resolved value, which is never a slice.
+*/
+type matcher func(actual any) bool
+
+func (b *builder) predicate(node searchquerylexer.Node) (Predicate, error) {
+	switch n := node.(type) {
+	case *searchquerylexer.BinaryExpr:
+		left, err := b.predicate(n.Left)
+
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := b.predicate(n.Right)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n.Connective == searchquerylexer.ConnectiveOr {
+			return func(value any) bool { return left(value) || right(value) }, nil
+		}
+
+		return func(value any) bool { return left(value) && right(value) }, nil
+
+	case *searchquerylexer.Group:
+		return b.predicate(n.Expr)
+
+	case *searchquerylexer.NotExpr:
+		inner, err := b.predicate(n.Expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return func(value any) bool { return !inner(value) }, nil
+
+	case *searchquerylexer.Comparison:
+		return b.comparison(n)
+
+	case *searchquerylexer.Range:
+		return b.field(n.Field.Value, rangeMatcher(n), false)
+
+	case *searchquerylexer.FreeText:
+		return b.freeText(n)
+	}
+
+	return nil, fmt.Errorf("%T: %w", node, searchquerylexer.ErrUnsupportedNode)
+}
+
+func (b *builder) comparison(n *searchquerylexer.Comparison) (Predicate, error) {
+	switch n.Operator {
+	case searchquerylexer.OperatorIn, searchquerylexer.OperatorNotIn:
+		expected := searchquerylexer.TypedValues(n.Values)
+
+		in := func(actual any) bool {
+			for _, value := range expected {
+				if order, ok := compare(actual, value); ok && order == 0 {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		return b.field(n.Field.Value, in, n.Operator == searchquerylexer.OperatorNotIn)
+	}
+
+	switch value := n.Value.TypedValue.(type) {
+	case searchquerylexer.Pattern:
+		// LIKE matches anywhere within the value, ignoring case, while EQUAL has to match all of it
+		if n.Operator == searchquerylexer.OperatorLike || n.Operator == searchquerylexer.OperatorNotLike {
+			return b.match(n, regexp.MustCompile("(?i)"+value.ContainsRegexp()))
+		}
+
+		return b.match(n, regexp.MustCompile(value.Regexp()))
+
+	case searchquerylexer.Regex:
+		if value.Regexp == nil {
+			return nil, fmt.Errorf("regular expression '%s' was not compiled: %w", value.Pattern, searchquerylexer.ErrUnsupportedOperator)
+		}
+
+		return b.match(n, value.Regexp)
+	}
+
+	expected := n.Value.Typed()
+	field := n.Field.Value
+
+	orderedBy := func(accept func(order int) bool) matcher {
+		return func(actual any) bool {
+			order, ok := compare(actual, expected)
+			return ok && accept(order)
+		}
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual:
+		return b.field(field, orderedBy(func(order int) bool { return order == 0 }), false)
+
+	case searchquerylexer.OperatorNotEqual:
+		return b.field(field, orderedBy(func(order int) bool { return order == 0 }), true)
+
+	case searchquerylexer.OperatorLessThan:
+		return b.field(field, orderedBy(func(order int) bool { return order < 0 }), false)
+
+	case searchquerylexer.OperatorGreaterThan:
+		return b.field(field, orderedBy(func(order int) bool { return order > 0 }), false)
+
+	case searchquerylexer.OperatorLessThanEqualTo:
+		return b.field(field, orderedBy(func(order int) bool { return order <= 0 }), false)
+
+	case searchquerylexer.OperatorGreaterThanEqualTo:
+		return b.field(field, orderedBy(func(order int) bool { return order >= 0 }), false)
+
+	case searchquerylexer.OperatorLike:
+		return b.field(field, contains(n.Value.Value), false)
+
+	case searchquerylexer.OperatorNotLike:
+		return b.field(field, contains(n.Value.Value), true)
+	}
+
+	return nil, fmt.Errorf("'%s': %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+/*
+match tests a field against a pattern or regular expression, negating
+it for the NOT EQUAL and NOT LIKE comparators.
+*/
+func (b *builder) match(n *searchquerylexer.Comparison, re *regexp.Regexp) (Predicate, error) {
+	matches := func(actual any) bool {
+		return re.MatchString(text(actual))
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual, searchquerylexer.OperatorLike:
+		return b.field(n.Field.Value, matches, false)
+
+	case searchquerylexer.OperatorNotEqual, searchquerylexer.OperatorNotLike:
+		return b.field(n.Field.Value, matches, true)
+	}
+
+	return nil, fmt.Errorf("'%s' with a pattern: %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+/*
+freeText matches a record when any free text field contains the term,
+ignoring case.
+*/
+func (b *builder) freeText(n *searchquerylexer.FreeText) (Predicate, error) {
+	fields := b.config.FreeTextFields()
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("'%s': %w", n.Value.Value, searchquerylexer.ErrNoDefaultFields)
+	}
+
+	like := contains(n.Value.Value)
+
+	// Unlike a plain term, a pattern is not a substring match
+	if pattern, ok := n.Value.TypedValue.(searchquerylexer.Pattern); ok {
+		re := regexp.MustCompile("(?i)" + pattern.Regexp())
+
+		like = func(actual any) bool {
+			return re.MatchString(text(actual))
+		}
+	}
+
+	predicates := make([]Predicate, 0, len(fields))
+
+	for _, field := range fields {
+		predicate, err := b.field(field.Name, like, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		predicates = append(predicates, predicate)
+	}
+
+	return func(value any) bool {
+		for _, predicate := range predicates {
+			if predicate(value) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+/*
+field builds a predicate that resolves fieldName and tests it with
+test. Slices match when any of their elements do. Negated predicates
+match when the positive one does not.
+*/
+func (b *builder) field(fieldName string, test matcher, negate bool) (Predicate, error) {
+	field, ok := b.config.Field(fieldName)
+
+	if !ok {
+		return nil, fmt.Errorf("'%s': %w", fieldName, searchquerylexer.ErrUnknownField)
+	}
+
+	path := field.Name
+
+	if b.options.ColumnPaths {
+		path = field.ColumnName()
+	}
+
+	segments := strings.Split(path, ".")
+
+	predicate := func(value any) bool {
+		actual, ok := resolve(value, segments, b.options.Tag)
+
+		if !ok {
+			return negate
+		}
+
+		return anyElement(actual, test) != negate
+	}
+
+	return predicate, nil
+}
+
+func rangeMatcher(n *searchquerylexer.Range) matcher {
+	return func(actual any) bool {
+		if n.Lower != nil {
+			order, ok := compare(actual, n.Lower.Typed())
+
+			if !ok || order < 0 || (order == 0 && !n.IncludeLower) {
+				return false
+			}
+		}
+
+		if n.Upper != nil {
+			order, ok := compare(actual, n.Upper.Typed())
+
+			if !ok || order > 0 || (order == 0 && !n.IncludeUpper) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+/*
+contains is a case-insensitive substring match.
+*/
+func contains(value string) matcher {
+	want := strings.ToLower(value)
+
+	return func(actual any) bool {
+		return strings.Contains(strings.ToLower(text(actual)), want)
+	}
+}
@@ -0,0 +1,268 @@
+package evaluator_test
+
+import (
+	"testing"
+	"time"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+	"github.com/adampresley/search-query-lexer/evaluator"
+	"github.com/stretchr/testify/assert"
+)
+
+type author struct {
+	Name string `json:"name"`
+}
+
+type audit struct {
+	Created time.Time
+}
+
+type book struct {
+	audit
+
+	Title    string        `json:"title"`
+	Pages    int32         `json:"pages"`
+	Price    float64       `json:"price,omitempty"`
+	Author   *author       `json:"author"`
+	Tags     []string      `json:"tags"`
+	Length   time.Duration `json:"length"`
+	InPrint  bool          `json:"in_print"`
+	Internal string        `json:"-"`
+}
+
+func testConfig() searchquerylexer.Config {
+	return searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"tags",
+			"price",
+			"internal",
+			"missing",
+		},
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "author", Column: "author.name"},
+			{Name: "pages", Type: searchquerylexer.FieldTypeInt},
+			{Name: "created", Type: searchquerylexer.FieldTypeTime},
+			{Name: "length", Type: searchquerylexer.FieldTypeDuration},
+			{Name: "inPrint", Column: "in_print", Type: searchquerylexer.FieldTypeBool},
+		},
+		DefaultFields: []string{"title", "author"},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	goBook := book{
+		audit:   audit{Created: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)},
+		Title:   "Go in Action",
+		Pages:   264,
+		Price:   39.99,
+		Author:  &author{Name: "William Kennedy"},
+		Tags:    []string{"go", "programming"},
+		Length:  9 * time.Hour,
+		InPrint: true,
+	}
+
+	goMap := map[string]any{
+		"title":    "Go in Action",
+		"pages":    264,
+		"price":    "39.99",
+		"author":   map[string]any{"name": "William Kennedy"},
+		"tags":     []any{"go", "programming"},
+		"Created":  time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+		"length":   "9h",
+		"in_print": true,
+	}
+
+	table := []struct {
+		input string
+		want  bool
+	}{
+		{input: `title = "Go in Action"`, want: true},
+		{input: `title = "go in action"`, want: false},
+		{input: `title != "Go in Action"`, want: false},
+		{input: `title =~ "IN act"`, want: true},
+		{input: `title !~ "in act"`, want: false},
+		{input: `title = Go*`, want: true},
+		{input: `title =~ g?`, want: true},
+		{input: `title = /^Go\b/`, want: true},
+		{input: `title != /action/i`, want: false},
+		{input: `pages > 200 and pages <= 264`, want: true},
+		{input: `pages < 264`, want: false},
+		{input: `pages in (100, 264)`, want: true},
+		{input: `pages not in (100, 264)`, want: false},
+		{input: `pages between 200 and 300`, want: true},
+		{input: `pages = {264 TO *]`, want: false},
+		{input: `price > 39.5 and price < 40`, want: true},
+		{input: `price >= 40`, want: false},
+		{input: `created >= 2020-01-01 and created < 2021-01-01`, want: true},
+		{input: `created > 2020-05-01`, want: false},
+		{input: `length > 8h`, want: true},
+		{input: `inPrint = true`, want: true},
+		{input: `author = "William Kennedy"`, want: true},
+		{input: `author =~ kennedy or title = nope`, want: true},
+		{input: `tags = go`, want: true},
+		{input: `tags != go`, want: false},
+		{input: `tags in (rust, programming)`, want: true},
+		{input: `not (title = nope or pages = 1)`, want: true},
+		{input: `missing = something`, want: false},
+		{input: `missing != something`, want: true},
+		{input: `action`, want: true},
+		{input: `kenn*`, want: false},
+		{input: `will*`, want: true},
+		{input: `"rust"`, want: false},
+	}
+
+	compiler, err := evaluator.NewCompiler(testConfig(), evaluator.Options{ColumnPaths: true})
+	assert.NoError(t, err)
+
+	for _, tt := range table {
+		t.Run(tt.input, func(t *testing.T) {
+			predicate, err := compiler.Compile(tt.input)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, predicate(goBook), "struct")
+			assert.Equal(t, tt.want, predicate(&goBook), "pointer to struct")
+			assert.Equal(t, tt.want, predicate(goMap), "map")
+		})
+	}
+}
+
+func TestCompileFieldResolution(t *testing.T) {
+	type tagged struct {
+		Name string `search:"title"`
+	}
+
+	table := []struct {
+		name    string
+		input   string
+		options evaluator.Options
+		value   any
+		want    bool
+	}{
+		{
+			name:  "fields ignored by the tag are not resolved",
+			input: `internal = secret`,
+			value: book{Internal: "secret"},
+			want:  false,
+		},
+		{
+			name:    "custom tag",
+			input:   `title = a`,
+			options: evaluator.Options{Tag: "search"},
+			value:   tagged{Name: "a"},
+			want:    true,
+		},
+		{
+			name:  "nil pointers are missing",
+			input: `author = a`,
+			value: book{},
+			want:  false,
+		},
+		{
+			name:  "map keys ignore case",
+			input: `title = a`,
+			value: map[string]string{"Title": "a"},
+			want:  true,
+		},
+		{
+			name:  "other values have no fields",
+			input: `title = a`,
+			value: "a",
+			want:  false,
+		},
+		{
+			name:  "nil",
+			input: `title != a`,
+			value: nil,
+			want:  true,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler, err := evaluator.NewCompiler(testConfig(), tt.options)
+			assert.NoError(t, err)
+
+			predicate, err := compiler.Compile(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, predicate(tt.value))
+		})
+	}
+}
+
+func TestCompileSharedConfig(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "title", Column: "documents.title_text"},
+			{Name: "pages", Column: "documents.page_count", Type: searchquerylexer.FieldTypeInt},
+		},
+	}
+
+	goBook := book{Title: "Go in Action", Pages: 264}
+
+	compiler, err := evaluator.NewCompiler(config, evaluator.Options{})
+	assert.NoError(t, err)
+
+	predicate, err := compiler.Compile(`title = "Go in Action" and pages > 200`)
+	assert.NoError(t, err)
+	assert.True(t, predicate(goBook))
+
+	predicate, err = compiler.Compile(`title != "Go in Action"`)
+	assert.NoError(t, err)
+	assert.False(t, predicate(goBook))
+
+	// The SQL columns do not exist in memory
+	compiler, err = evaluator.NewCompiler(config, evaluator.Options{ColumnPaths: true})
+	assert.NoError(t, err)
+
+	predicate, err = compiler.Compile(`title = "Go in Action"`)
+	assert.NoError(t, err)
+	assert.False(t, predicate(goBook))
+}
+
+func TestCompileErrors(t *testing.T) {
+	config := testConfig()
+	config.DefaultFields = nil
+
+	compiler, err := evaluator.NewCompiler(config, evaluator.Options{})
+	assert.NoError(t, err)
+
+	_, err = compiler.Compile(`invoice`)
+	assert.ErrorIs(t, err, searchquerylexer.ErrNoDefaultFields)
+
+	_, err = compiler.Compile(`title = `)
+	assert.ErrorIs(t, err, searchquerylexer.ErrUnexpectedEndOfInput)
+
+	node := &searchquerylexer.Comparison{
+		Field:      searchquerylexer.NewToken(searchquerylexer.TokenTypeFieldName, "unknown"),
+		Comparator: searchquerylexer.NewToken(searchquerylexer.TokenTypeComparator, "!="),
+		Operator:   searchquerylexer.OperatorNotEqual,
+		Value:      searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+	}
+
+	_, err = evaluator.CompileNode(node, config, evaluator.Options{})
+	assert.ErrorIs(t, err, searchquerylexer.ErrUnknownField)
+}
+
+func TestCompileTokens(t *testing.T) {
+	config := testConfig()
+
+	lexer, err := searchquerylexer.NewLexer(config)
+	assert.NoError(t, err)
+
+	compiler, err := evaluator.NewCompiler(config, evaluator.Options{})
+	assert.NoError(t, err)
+
+	tokens, err := lexer.Tokenize(`pages >= 10`)
+	assert.NoError(t, err)
+
+	predicate, err := compiler.CompileTokens(tokens)
+	assert.NoError(t, err)
+
+	assert.True(t, predicate(map[string]any{"pages": 10}))
+	assert.False(t, predicate(map[string]any{"pages": 9}))
+}
@@ -0,0 +1,349 @@
+package evaluator
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+/*
+resolve walks path through nested maps and structs, following pointers
+and interfaces along the way. Map keys and field names are matched
+exactly first, then ignoring case.
+*/
+func resolve(value any, path []string, tag string) (any, bool) {
+	current := reflect.ValueOf(value)
+
+	for _, segment := range path {
+		current = indirect(current)
+
+		if !current.IsValid() {
+			return nil, false
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			if current.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+
+			current = mapIndex(current, segment)
+
+		case reflect.Struct:
+			current = structField(current, segment, tag)
+
+		default:
+			return nil, false
+		}
+
+		if !current.IsValid() {
+			return nil, false
+		}
+	}
+
+	current = indirect(current)
+
+	if !current.IsValid() {
+		return nil, false
+	}
+
+	return current.Interface(), true
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+
+		value = value.Elem()
+	}
+
+	return value
+}
+
+func mapIndex(m reflect.Value, key string) reflect.Value {
+	if result := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key())); result.IsValid() {
+		return result
+	}
+
+	iter := m.MapRange()
+
+	for iter.Next() {
+		if strings.EqualFold(iter.Key().String(), key) {
+			return iter.Value()
+		}
+	}
+
+	return reflect.Value{}
+}
+
+/*
+structField finds the exported field known as name, either through its
+tag or its Go name. Fields of embedded structs are searched as well.
+*/
+func structField(s reflect.Value, name, tag string) reflect.Value {
+	var (
+		folded reflect.Value
+	)
+
+	t := s.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldName, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+
+		if fieldName == "-" {
+			continue
+		}
+
+		// The exported fields of embedded structs are promoted, as in encoding/json
+		if field.Anonymous && fieldName == "" {
+			embedded := indirect(s.Field(i))
+
+			if embedded.Kind() == reflect.Struct {
+				if result := structField(embedded, name, tag); result.IsValid() {
+					return result
+				}
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		if fieldName == name {
+			return s.Field(i)
+		}
+
+		if !folded.IsValid() && strings.EqualFold(fieldName, name) {
+			folded = s.Field(i)
+		}
+	}
+
+	return folded
+}
+
+/*
+anyElement applies test to value, or to each element when value is a
+slice or array, reporting whether any of them passed.
+*/
+func anyElement(value any, test matcher) bool {
+	v := reflect.ValueOf(value)
+
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+		return test(value)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		element := indirect(v.Index(i))
+
+		if element.IsValid() && test(element.Interface()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+compare orders actual against expected, converting actual to the type
+of expected first. It returns false when the two cannot be compared.
+*/
+func compare(actual, expected any) (int, bool) {
+	actual = normalize(actual)
+
+	// Values of untyped fields are strings, so follow the data instead
+	if want, ok := expected.(string); ok {
+		switch got := actual.(type) {
+		case string:
+			return strings.Compare(got, want), true
+
+		case int64, uint64, float64, bool, time.Time, time.Duration:
+			converted, ok := parseAs(want, got)
+
+			if !ok {
+				return 0, false
+			}
+
+			expected = converted
+
+		default:
+			return strings.Compare(text(got), want), true
+		}
+	}
+
+	switch want := expected.(type) {
+	case int64:
+		switch got := actual.(type) {
+		case int64:
+			return cmp.Compare(got, want), true
+
+		case uint64:
+			if got > math.MaxInt64 {
+				return 1, true
+			}
+
+			return cmp.Compare(int64(got), want), true
+
+		case float64:
+			return cmp.Compare(got, float64(want)), true
+		}
+
+	case float64:
+		switch got := actual.(type) {
+		case int64:
+			return cmp.Compare(float64(got), want), true
+
+		case uint64:
+			return cmp.Compare(float64(got), want), true
+
+		case float64:
+			return cmp.Compare(got, want), true
+		}
+
+	case bool:
+		if got, ok := actual.(bool); ok {
+			return compareBool(got, want), true
+		}
+
+	case time.Time:
+		if got, ok := actual.(time.Time); ok {
+			return got.Compare(want), true
+		}
+
+	case time.Duration:
+		if got, ok := actual.(time.Duration); ok {
+			return cmp.Compare(got, want), true
+		}
+	}
+
+	// Strings compared against a typed field are parsed first
+	if got, ok := actual.(string); ok {
+		if converted, ok := parseAs(got, expected); ok {
+			return compare(converted, expected)
+		}
+	}
+
+	return 0, false
+}
+
+/*
+normalize turns value into one of string, int64, uint64, float64, bool,
+time.Time, or time.Duration, so that named types and the various sizes
+of numbers compare alike. Anything else is left as it is.
+*/
+func normalize(value any) any {
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int())
+
+	case v.Type().ConvertibleTo(timeType) && v.Kind() == reflect.Struct:
+		return v.Convert(timeType).Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+
+	case reflect.Bool:
+		return v.Bool()
+	}
+
+	return value
+}
+
+/*
+parseAs parses text into the type of like, which is a number, bool,
+time, or duration.
+*/
+func parseAs(text string, like any) (any, bool) {
+	switch like.(type) {
+	case int64, uint64, float64:
+		if result, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return result, true
+		}
+
+		if result, err := strconv.ParseFloat(text, 64); err == nil {
+			return result, true
+		}
+
+	case bool:
+		if result, err := strconv.ParseBool(text); err == nil {
+			return result, true
+		}
+
+	case time.Time:
+		for _, layout := range searchquerylexer.TimeLayouts {
+			if result, err := time.Parse(layout, text); err == nil {
+				return result, true
+			}
+		}
+
+	case time.Duration:
+		if result, err := time.ParseDuration(text); err == nil {
+			return result, true
+		}
+	}
+
+	return nil, false
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+
+	case !a:
+		return -1
+	}
+
+	return 1
+}
+
+/*
+text returns the string form of a value for LIKE and pattern matches.
+*/
+func text(value any) string {
+	switch v := normalize(value).(type) {
+	case string:
+		return v
+
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprint(value)
+}
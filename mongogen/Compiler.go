@@ -0,0 +1,321 @@
+/*
+Package mongogen compiles search queries into MongoDB filter documents.
+Filters are plain map[string]any values, so this package does not depend
+on a MongoDB driver. They can be passed to the driver as they are, or
+converted to bson.M.
+*/
+package mongogen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+// RegexOptions are the regular expression flags MongoDB accepts in $options
+const RegexOptions = "imsx"
+
+/*
+Options changes how filters are generated. CaseInsensitiveLike makes
+LIKE comparisons and free text ignore case.
+*/
+type Options struct {
+	CaseInsensitiveLike bool
+}
+
+type Compiler struct {
+	config  searchquerylexer.Config
+	parser  *searchquerylexer.Parser
+	options Options
+}
+
+func NewCompiler(config searchquerylexer.Config, options Options) (*Compiler, error) {
+	parser, err := searchquerylexer.NewParser(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Compiler{
+		config:  config,
+		parser:  parser,
+		options: options,
+	}
+
+	return result, nil
+}
+
+/*
+Compile parses input and returns a filter document.
+*/
+func (c *Compiler) Compile(input string) (map[string]any, error) {
+	node, err := c.parser.Parse(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileTokens returns the filter document for tokens from
+Lexer.Tokenize.
+*/
+func (c *Compiler) CompileTokens(tokens []*searchquerylexer.Token) (map[string]any, error) {
+	node, err := c.parser.ParseTokens(tokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileNode turns a parsed tree into a filter document. Field names are
+replaced with the document path configured as their Column, such as
+author.name. A field that is not configured fails with ErrUnknownField.
+*/
+func CompileNode(node searchquerylexer.Node, config searchquerylexer.Config, options Options) (map[string]any, error) {
+	b := &builder{
+		config:  config,
+		options: options,
+	}
+
+	return b.filter(node)
+}
+
+type builder struct {
+	config  searchquerylexer.Config
+	options Options
+}
+
+func (b *builder) filter(node searchquerylexer.Node) (map[string]any, error) {
+	switch n := node.(type) {
+	case *searchquerylexer.BinaryExpr:
+		return b.binary(n)
+
+	case *searchquerylexer.Group:
+		return b.filter(n.Expr)
+
+	case *searchquerylexer.NotExpr:
+		// MongoDB has no top level $not, but $nor of one filter is the same
+		filter, err := b.filter(n.Expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"$nor": []any{filter}}, nil
+
+	case *searchquerylexer.Comparison:
+		return b.comparison(n)
+
+	case *searchquerylexer.Range:
+		return b.rangeFilter(n)
+
+	case *searchquerylexer.FreeText:
+		return b.freeText(n)
+	}
+
+	return nil, fmt.Errorf("%T: %w", node, searchquerylexer.ErrUnsupportedNode)
+}
+
+/*
+binary turns a chain of the same connective, such as a and b and c,
+into a single $and or $or.
+*/
+func (b *builder) binary(n *searchquerylexer.BinaryExpr) (map[string]any, error) {
+	key := "$and"
+
+	if n.Connective == searchquerylexer.ConnectiveOr {
+		key = "$or"
+	}
+
+	filters := []any{}
+
+	for _, child := range []searchquerylexer.Node{n.Left, n.Right} {
+		filter, err := b.filter(child)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nested, ok := child.(*searchquerylexer.BinaryExpr)
+
+		if ok && nested.Connective == n.Connective {
+			filters = append(filters, filter[key].([]any)...)
+			continue
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return map[string]any{key: filters}, nil
+}
+
+func (b *builder) comparison(n *searchquerylexer.Comparison) (map[string]any, error) {
+	path, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorIn:
+		return map[string]any{path: map[string]any{"$in": searchquerylexer.TypedValues(n.Values)}}, nil
+
+	case searchquerylexer.OperatorNotIn:
+		return map[string]any{path: map[string]any{"$nin": searchquerylexer.TypedValues(n.Values)}}, nil
+	}
+
+	switch value := n.Value.TypedValue.(type) {
+	case searchquerylexer.Pattern:
+		if n.Operator == searchquerylexer.OperatorEqual || n.Operator == searchquerylexer.OperatorNotEqual {
+			return b.match(path, n, value.Regexp(), "")
+		}
+
+		return b.match(path, n, value.ContainsRegexp(), "")
+
+	case searchquerylexer.Regex:
+		for _, flag := range value.Flags {
+			if !strings.ContainsRune(RegexOptions, flag) {
+				return nil, fmt.Errorf("regular expression flag '%c': %w", flag, searchquerylexer.ErrUnsupportedOperator)
+			}
+		}
+
+		return b.match(path, n, value.Pattern, value.Flags)
+	}
+
+	value := n.Value.Typed()
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual:
+		return map[string]any{path: map[string]any{"$eq": value}}, nil
+
+	case searchquerylexer.OperatorNotEqual:
+		return map[string]any{path: map[string]any{"$ne": value}}, nil
+
+	case searchquerylexer.OperatorLessThan:
+		return map[string]any{path: map[string]any{"$lt": value}}, nil
+
+	case searchquerylexer.OperatorGreaterThan:
+		return map[string]any{path: map[string]any{"$gt": value}}, nil
+
+	case searchquerylexer.OperatorLessThanEqualTo:
+		return map[string]any{path: map[string]any{"$lte": value}}, nil
+
+	case searchquerylexer.OperatorGreaterThanEqualTo:
+		return map[string]any{path: map[string]any{"$gte": value}}, nil
+
+	case searchquerylexer.OperatorLike, searchquerylexer.OperatorNotLike:
+		return b.match(path, n, regexp.QuoteMeta(n.Value.Value), b.likeOptions())
+	}
+
+	return nil, fmt.Errorf("'%s': %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+/*
+match compares path against a regular expression, negating it for the
+NOT EQUAL and NOT LIKE comparators.
+*/
+func (b *builder) match(path string, n *searchquerylexer.Comparison, pattern, flags string) (map[string]any, error) {
+	regex := map[string]any{"$regex": pattern}
+
+	if flags != "" {
+		regex["$options"] = flags
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual, searchquerylexer.OperatorLike:
+		return map[string]any{path: regex}, nil
+
+	case searchquerylexer.OperatorNotEqual, searchquerylexer.OperatorNotLike:
+		return map[string]any{path: map[string]any{"$not": regex}}, nil
+	}
+
+	return nil, fmt.Errorf("'%s' with a pattern: %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+}
+
+func (b *builder) rangeFilter(n *searchquerylexer.Range) (map[string]any, error) {
+	path, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := map[string]any{}
+
+	if n.Lower != nil {
+		operator := "$gt"
+
+		if n.IncludeLower {
+			operator = "$gte"
+		}
+
+		conditions[operator] = n.Lower.Typed()
+	}
+
+	if n.Upper != nil {
+		operator := "$lt"
+
+		if n.IncludeUpper {
+			operator = "$lte"
+		}
+
+		conditions[operator] = n.Upper.Typed()
+	}
+
+	// Both bounds open only requires a value
+	if len(conditions) == 0 {
+		conditions["$ne"] = nil
+	}
+
+	return map[string]any{path: conditions}, nil
+}
+
+/*
+freeText builds a $regex filter per free text field, combined with $or
+when there is more than one.
+*/
+func (b *builder) freeText(n *searchquerylexer.FreeText) (map[string]any, error) {
+	fields := b.config.FreeTextFields()
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("'%s': %w", n.Value.Value, searchquerylexer.ErrNoDefaultFields)
+	}
+
+	regex := map[string]any{"$regex": regexp.QuoteMeta(n.Value.Value)}
+
+	// Patterns are anchored, where a plain term matches as a substring
+	if pattern, ok := n.Value.TypedValue.(searchquerylexer.Pattern); ok {
+		regex["$regex"] = pattern.Regexp()
+	}
+
+	if options := b.likeOptions(); options != "" {
+		regex["$options"] = options
+	}
+
+	filters := make([]any, 0, len(fields))
+
+	for _, field := range fields {
+		filters = append(filters, map[string]any{field.ColumnName(): regex})
+	}
+
+	if len(filters) == 1 {
+		return filters[0].(map[string]any), nil
+	}
+
+	return map[string]any{"$or": filters}, nil
+}
+
+func (b *builder) likeOptions() string {
+	if b.options.CaseInsensitiveLike {
+		return "i"
+	}
+
+	return ""
+}
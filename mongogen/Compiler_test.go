@@ -0,0 +1,234 @@
+package mongogen_test
+
+import (
+	"testing"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+	"github.com/adampresley/search-query-lexer/mongogen"
+	"github.com/stretchr/testify/assert"
+)
+
+type filter = map[string]any
+
+func TestCompile(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"category",
+		},
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "author", Column: "author.name"},
+			{Name: "age", Type: searchquerylexer.FieldTypeInt},
+		},
+		DefaultFields: []string{"title", "author"},
+	}
+
+	table := []struct {
+		name        string
+		input       string
+		options     mongogen.Options
+		want        filter
+		expectedErr error
+	}{
+		{
+			name:  "comparisons",
+			input: `title = a and age < 3 and age >= 1 and category != b`,
+			want: filter{"$and": []any{
+				filter{"title": filter{"$eq": "a"}},
+				filter{"age": filter{"$lt": int64(3)}},
+				filter{"age": filter{"$gte": int64(1)}},
+				filter{"category": filter{"$ne": "b"}},
+			}},
+		},
+		{
+			name:  "subqueries",
+			input: `(title=~"a.b" AND age > 30) OR (category !~ "bad")`,
+			want: filter{"$or": []any{
+				filter{"$and": []any{
+					filter{"title": filter{"$regex": `a\.b`}},
+					filter{"age": filter{"$gt": int64(30)}},
+				}},
+				filter{"category": filter{"$not": filter{"$regex": "bad"}}},
+			}},
+		},
+		{
+			name:    "case insensitive like",
+			input:   `title =~ a`,
+			options: mongogen.Options{CaseInsensitiveLike: true},
+			want:    filter{"title": filter{"$regex": "a", "$options": "i"}},
+		},
+		{
+			name:  "dotted paths",
+			input: `author = "Adam"`,
+			want:  filter{"author.name": filter{"$eq": "Adam"}},
+		},
+		{
+			name:  "negation",
+			input: `not (title = a or title = b)`,
+			want: filter{"$nor": []any{
+				filter{"$or": []any{
+					filter{"title": filter{"$eq": "a"}},
+					filter{"title": filter{"$eq": "b"}},
+				}},
+			}},
+		},
+		{
+			name:  "lists",
+			input: `age in (1, 2) and category not in (x)`,
+			want: filter{"$and": []any{
+				filter{"age": filter{"$in": []any{int64(1), int64(2)}}},
+				filter{"category": filter{"$nin": []any{"x"}}},
+			}},
+		},
+		{
+			name:  "ranges",
+			input: `age between 18 and 30 or age = {1 to *]`,
+			want: filter{"$or": []any{
+				filter{"age": filter{"$gte": int64(18), "$lte": int64(30)}},
+				filter{"age": filter{"$gt": int64(1)}},
+			}},
+		},
+		{
+			name:  "patterns",
+			input: `title = foo.* and category != j?n`,
+			want: filter{"$and": []any{
+				filter{"title": filter{"$regex": `(?s)^foo\..*$`}},
+				filter{"category": filter{"$not": filter{"$regex": `(?s)^j.n$`}}},
+			}},
+		},
+		{
+			name:  "regular expressions",
+			input: `title =~ /timeout after \d+ms/i`,
+			want:  filter{"title": filter{"$regex": `timeout after \d+ms`, "$options": "i"}},
+		},
+		{
+			name:  "free text",
+			input: `"a+b"`,
+			want: filter{"$or": []any{
+				filter{"title": filter{"$regex": `a\+b`}},
+				filter{"author.name": filter{"$regex": `a\+b`}},
+			}},
+		},
+		{
+			name:        "unsupported regular expression flag",
+			input:       `title =~ /a/U`,
+			expectedErr: searchquerylexer.ErrUnsupportedOperator,
+		},
+		{
+			name:        "parse errors are returned",
+			input:       `title =`,
+			expectedErr: searchquerylexer.ErrUnexpectedEndOfInput,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler, err := mongogen.NewCompiler(config, tt.options)
+			assert.NoError(t, err)
+
+			got, err := compiler.Compile(tt.input)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("flags of a hand built regular expression", func(t *testing.T) {
+		value := searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "/a/g")
+		value.Kind = searchquerylexer.ValueKindRegex
+		value.TypedValue = searchquerylexer.Regex{Pattern: "a", Flags: "g"}
+
+		node := &searchquerylexer.Comparison{
+			Field:      searchquerylexer.NewToken(searchquerylexer.TokenTypeFieldName, "title"),
+			Comparator: searchquerylexer.NewToken(searchquerylexer.TokenTypeComparator, "=~"),
+			Operator:   searchquerylexer.OperatorLike,
+			Value:      value,
+		}
+
+		_, err := mongogen.CompileNode(node, config, mongogen.Options{})
+		assert.ErrorIs(t, err, searchquerylexer.ErrUnsupportedOperator)
+
+		value.TypedValue = searchquerylexer.Regex{Pattern: "a", Flags: "mx"}
+
+		got, err := mongogen.CompileNode(node, config, mongogen.Options{})
+		assert.NoError(t, err)
+		assert.Equal(t, filter{"title": filter{"$regex": "a", "$options": "mx"}}, got)
+	})
+}
+
+func TestCompileTokens(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := searchquerylexer.NewLexer(config)
+	assert.NoError(t, err)
+
+	compiler, err := mongogen.NewCompiler(config, mongogen.Options{})
+	assert.NoError(t, err)
+
+	tokens, err := lexer.Tokenize(`title = a`)
+	assert.NoError(t, err)
+
+	got, err := compiler.CompileTokens(tokens)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filter{"title": filter{"$eq": "a"}}, got)
+
+	_, err = compiler.Compile(`invoice`)
+	assert.ErrorIs(t, err, searchquerylexer.ErrNoDefaultFields)
+}
+
+func TestCompileUnknownField(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	field := searchquerylexer.NewToken(searchquerylexer.TokenTypeFieldName, "$where")
+
+	table := []struct {
+		name string
+		node searchquerylexer.Node
+	}{
+		{
+			name: "comparison",
+			node: &searchquerylexer.Comparison{
+				Field:      field,
+				Comparator: searchquerylexer.NewToken(searchquerylexer.TokenTypeComparator, "="),
+				Operator:   searchquerylexer.OperatorEqual,
+				Value:      searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "sleep(1000)"),
+			},
+		},
+		{
+			name: "range",
+			node: &searchquerylexer.Range{
+				Field: field,
+				Lower: searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+			},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mongogen.CompileNode(tt.node, config, mongogen.Options{})
+
+			assert.ErrorIs(t, err, searchquerylexer.ErrUnknownField)
+			assert.Nil(t, got)
+		})
+	}
+}
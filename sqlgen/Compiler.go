@@ -0,0 +1,432 @@
+/*
+Package sqlgen compiles search queries into SQL WHERE clause fragments.
+Values are never written into the SQL itself. Instead they are returned
+as arguments to be bound to placeholders by the database driver.
+*/
+package sqlgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+)
+
+type PlaceholderStyle string
+
+const (
+	// PlaceholderQuestion produces ?, as used by MySQL and SQLite
+	PlaceholderQuestion PlaceholderStyle = "?"
+	// PlaceholderDollar produces $1, $2, as used by PostgreSQL
+	PlaceholderDollar PlaceholderStyle = "$"
+	// PlaceholderAtP produces @p1, @p2, as used by SQL Server
+	PlaceholderAtP PlaceholderStyle = "@p"
+	// PlaceholderNamed produces :p1, :p2, as used by Oracle
+	PlaceholderNamed PlaceholderStyle = ":"
+)
+
+/*
+LikeEscape is the escape character used in generated LIKE clauses.
+It is not a backslash because backslash is itself an escape character
+in MySQL string literals.
+*/
+const LikeEscape = "!"
+
+type Options struct {
+	Placeholder PlaceholderStyle
+}
+
+type Compiler struct {
+	config  searchquerylexer.Config
+	parser  *searchquerylexer.Parser
+	options Options
+}
+
+func NewCompiler(config searchquerylexer.Config, options Options) (*Compiler, error) {
+	parser, err := searchquerylexer.NewParser(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Compiler{
+		config:  config,
+		parser:  parser,
+		options: options,
+	}
+
+	return result, nil
+}
+
+/*
+Compile parses input and returns a WHERE clause fragment, without the
+WHERE keyword, along with the arguments for its placeholders.
+*/
+func (c *Compiler) Compile(input string) (string, []any, error) {
+	node, err := c.parser.Parse(input)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileTokens parses tokens from Lexer.Tokenize, rather than an input
+string, and returns a WHERE clause fragment and its arguments.
+*/
+func (c *Compiler) CompileTokens(tokens []*searchquerylexer.Token) (string, []any, error) {
+	node, err := c.parser.ParseTokens(tokens)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return CompileNode(node, c.config, c.options)
+}
+
+/*
+CompileNode turns a parsed tree into a WHERE clause fragment and its
+arguments. Field names are replaced with the column configured for them
+in config, and a field that is not configured fails with
+ErrUnknownField.
+*/
+func CompileNode(node searchquerylexer.Node, config searchquerylexer.Config, options Options) (string, []any, error) {
+	b := &builder{
+		config:  config,
+		options: options,
+	}
+
+	if err := b.write(node); err != nil {
+		return "", nil, err
+	}
+
+	return b.sql.String(), b.args, nil
+}
+
+type builder struct {
+	config  searchquerylexer.Config
+	options Options
+	sql     strings.Builder
+	args    []any
+}
+
+func (b *builder) write(node searchquerylexer.Node) error {
+	switch n := node.(type) {
+	case *searchquerylexer.BinaryExpr:
+		return b.writeBinary(n)
+
+	case *searchquerylexer.Group:
+		b.sql.WriteString("(")
+
+		if err := b.write(n.Expr); err != nil {
+			return err
+		}
+
+		b.sql.WriteString(")")
+		return nil
+
+	case *searchquerylexer.NotExpr:
+		return b.writeNot(n)
+
+	case *searchquerylexer.Comparison:
+		return b.writeComparison(n)
+
+	case *searchquerylexer.Range:
+		return b.writeRange(n)
+
+	case *searchquerylexer.FreeText:
+		return b.writeFreeText(n)
+	}
+
+	return fmt.Errorf("%T: %w", node, searchquerylexer.ErrUnsupportedNode)
+}
+
+func (b *builder) writeBinary(n *searchquerylexer.BinaryExpr) error {
+	keyword := " AND "
+
+	if n.Connective == searchquerylexer.ConnectiveOr {
+		keyword = " OR "
+	}
+
+	for i, child := range []searchquerylexer.Node{n.Left, n.Right} {
+		if i > 0 {
+			b.sql.WriteString(keyword)
+		}
+
+		/*
+		 * The parser never puts an OR directly beneath an AND, but a
+		 * hand built tree might, so keep the meaning with parentheses.
+		 */
+		nested, ok := child.(*searchquerylexer.BinaryExpr)
+		wrap := ok && n.Connective == searchquerylexer.ConnectiveAnd && nested.Connective == searchquerylexer.ConnectiveOr
+
+		if wrap {
+			b.sql.WriteString("(")
+		}
+
+		if err := b.write(child); err != nil {
+			return err
+		}
+
+		if wrap {
+			b.sql.WriteString(")")
+		}
+	}
+
+	return nil
+}
+
+func (b *builder) writeNot(n *searchquerylexer.NotExpr) error {
+	b.sql.WriteString("NOT ")
+
+	// A group already brings its own parentheses
+	if _, ok := n.Expr.(*searchquerylexer.Group); ok {
+		return b.write(n.Expr)
+	}
+
+	b.sql.WriteString("(")
+
+	if err := b.write(n.Expr); err != nil {
+		return err
+	}
+
+	b.sql.WriteString(")")
+	return nil
+}
+
+func (b *builder) writeComparison(n *searchquerylexer.Comparison) error {
+	column, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return err
+	}
+
+	switch n.Operator {
+	case searchquerylexer.OperatorIn:
+		b.sql.WriteString(column + " IN " + b.bindList(n.Values))
+		return nil
+
+	case searchquerylexer.OperatorNotIn:
+		b.sql.WriteString(column + " NOT IN " + b.bindList(n.Values))
+		return nil
+	}
+
+	if pattern, ok := n.Value.TypedValue.(searchquerylexer.Pattern); ok {
+		return b.writePattern(column, n, pattern)
+	}
+
+	// Regular expression syntax differs too much between databases
+	if n.Value.Kind == searchquerylexer.ValueKindRegex {
+		return fmt.Errorf("'%s' with a regular expression: %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+	}
+
+	value := n.Value.Typed()
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual:
+		b.sql.WriteString(column + " = " + b.bind(value))
+
+	case searchquerylexer.OperatorNotEqual:
+		b.sql.WriteString(column + " <> " + b.bind(value))
+
+	case searchquerylexer.OperatorLessThan:
+		b.sql.WriteString(column + " < " + b.bind(value))
+
+	case searchquerylexer.OperatorGreaterThan:
+		b.sql.WriteString(column + " > " + b.bind(value))
+
+	case searchquerylexer.OperatorLessThanEqualTo:
+		b.sql.WriteString(column + " <= " + b.bind(value))
+
+	case searchquerylexer.OperatorGreaterThanEqualTo:
+		b.sql.WriteString(column + " >= " + b.bind(value))
+
+	case searchquerylexer.OperatorLike:
+		b.sql.WriteString(column + " LIKE " + b.bind("%"+EscapeLike(n.Value.Value)+"%") + " ESCAPE '" + LikeEscape + "'")
+
+	case searchquerylexer.OperatorNotLike:
+		b.sql.WriteString(column + " NOT LIKE " + b.bind("%"+EscapeLike(n.Value.Value)+"%") + " ESCAPE '" + LikeEscape + "'")
+
+	default:
+		return fmt.Errorf("'%s': %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+	}
+
+	return nil
+}
+
+/*
+writePattern compares a column against a value with wildcards. EQUAL
+matches the whole value, while LIKE still matches anywhere within it.
+*/
+func (b *builder) writePattern(column string, n *searchquerylexer.Comparison, pattern searchquerylexer.Pattern) error {
+	escape := " ESCAPE '" + LikeEscape + "'"
+
+	switch n.Operator {
+	case searchquerylexer.OperatorEqual:
+		b.sql.WriteString(column + " LIKE " + b.bind(LikePattern(pattern)) + escape)
+
+	case searchquerylexer.OperatorNotEqual:
+		b.sql.WriteString(column + " NOT LIKE " + b.bind(LikePattern(pattern)) + escape)
+
+	case searchquerylexer.OperatorLike:
+		b.sql.WriteString(column + " LIKE " + b.bind("%"+LikePattern(pattern)+"%") + escape)
+
+	case searchquerylexer.OperatorNotLike:
+		b.sql.WriteString(column + " NOT LIKE " + b.bind("%"+LikePattern(pattern)+"%") + escape)
+
+	default:
+		return fmt.Errorf("'%s' with wildcards: %w", n.Comparator.Value, searchquerylexer.ErrUnsupportedOperator)
+	}
+
+	return nil
+}
+
+func (b *builder) writeRange(n *searchquerylexer.Range) error {
+	column, err := b.config.Column(n.Field.Value)
+
+	if err != nil {
+		return err
+	}
+
+	if n.Lower == nil && n.Upper == nil {
+		b.sql.WriteString(column + " IS NOT NULL")
+		return nil
+	}
+
+	if n.Lower != nil && n.Upper != nil && n.IncludeLower && n.IncludeUpper {
+		b.sql.WriteString(column + " BETWEEN " + b.bind(n.Lower.Typed()) + " AND " + b.bind(n.Upper.Typed()))
+		return nil
+	}
+
+	conditions := []string{}
+
+	if n.Lower != nil {
+		operator := " > "
+
+		if n.IncludeLower {
+			operator = " >= "
+		}
+
+		conditions = append(conditions, column+operator+b.bind(n.Lower.Typed()))
+	}
+
+	if n.Upper != nil {
+		operator := " < "
+
+		if n.IncludeUpper {
+			operator = " <= "
+		}
+
+		conditions = append(conditions, column+operator+b.bind(n.Upper.Typed()))
+	}
+
+	if len(conditions) == 1 {
+		b.sql.WriteString(conditions[0])
+		return nil
+	}
+
+	b.sql.WriteString("(" + strings.Join(conditions, " AND ") + ")")
+	return nil
+}
+
+/*
+writeFreeText writes a LIKE condition per free text field, joined with
+OR.
+*/
+func (b *builder) writeFreeText(n *searchquerylexer.FreeText) error {
+	fields := b.config.FreeTextFields()
+
+	if len(fields) == 0 {
+		return fmt.Errorf("'%s': %w", n.Value.Value, searchquerylexer.ErrNoDefaultFields)
+	}
+
+	conditions := make([]string, 0, len(fields))
+	value := "%" + EscapeLike(n.Value.Value) + "%"
+
+	// LikePattern leaves the pattern unwrapped, so it is not a substring match
+	if pattern, ok := n.Value.TypedValue.(searchquerylexer.Pattern); ok {
+		value = LikePattern(pattern)
+	}
+
+	for _, field := range fields {
+		conditions = append(conditions, field.ColumnName()+" LIKE "+b.bind(value)+" ESCAPE '"+LikeEscape+"'")
+	}
+
+	if len(conditions) == 1 {
+		b.sql.WriteString(conditions[0])
+		return nil
+	}
+
+	b.sql.WriteString("(" + strings.Join(conditions, " OR ") + ")")
+	return nil
+}
+
+func (b *builder) bindList(values []*searchquerylexer.Token) string {
+	placeholders := make([]string, 0, len(values))
+
+	for _, value := range values {
+		placeholders = append(placeholders, b.bind(value.Typed()))
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+func (b *builder) bind(value any) string {
+	b.args = append(b.args, value)
+	index := strconv.Itoa(len(b.args))
+
+	switch b.options.Placeholder {
+	case PlaceholderDollar:
+		return "$" + index
+
+	case PlaceholderAtP:
+		return "@p" + index
+
+	case PlaceholderNamed:
+		return ":p" + index
+	}
+
+	return "?"
+}
+
+/*
+EscapeLike escapes the LIKE wildcards % and _, along with the escape
+character itself and the [ used by SQL Server character classes, so
+that value matches literally.
+*/
+func EscapeLike(value string) string {
+	replacer := strings.NewReplacer(
+		LikeEscape, LikeEscape+LikeEscape,
+		"%", LikeEscape+"%",
+		"_", LikeEscape+"_",
+		"[", LikeEscape+"[",
+	)
+
+	return replacer.Replace(value)
+}
+
+/*
+LikePattern turns a pattern into a LIKE pattern, with * becoming % and
+? becoming _. Everything else is escaped to match literally.
+*/
+func LikePattern(pattern searchquerylexer.Pattern) string {
+	var result strings.Builder
+
+	for _, part := range pattern {
+		switch part.Wildcard {
+		case searchquerylexer.WildcardAny:
+			result.WriteString("%")
+
+		case searchquerylexer.WildcardOne:
+			result.WriteString("_")
+
+		default:
+			result.WriteString(EscapeLike(part.Literal))
+		}
+	}
+
+	return result.String()
+}
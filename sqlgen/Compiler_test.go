@@ -0,0 +1,276 @@
+package sqlgen_test
+
+import (
+	"testing"
+
+	searchquerylexer "github.com/adampresley/search-query-lexer"
+	"github.com/adampresley/search-query-lexer/sqlgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+			"age",
+			"category",
+		},
+	}
+
+	table := []struct {
+		name        string
+		input       string
+		placeholder sqlgen.PlaceholderStyle
+		wantSQL     string
+		wantArgs    []any
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name:        "question placeholders",
+			input:       `(title=~"test" AND age >= 30) OR (category != "bad")`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `(title LIKE ? ESCAPE '!' AND age >= ?) OR (category <> ?)`,
+			wantArgs:    []any{"%test%", "30", "bad"},
+		},
+		{
+			name:        "dollar placeholders",
+			input:       `title = a or age < 3 and age > 1`,
+			placeholder: sqlgen.PlaceholderDollar,
+			wantSQL:     `title = $1 OR age < $2 AND age > $3`,
+			wantArgs:    []any{"a", "3", "1"},
+		},
+		{
+			name:        "at placeholders",
+			input:       `title != a and age <= 3`,
+			placeholder: sqlgen.PlaceholderAtP,
+			wantSQL:     `title <> @p1 AND age <= @p2`,
+			wantArgs:    []any{"a", "3"},
+		},
+		{
+			name:        "named placeholders",
+			input:       `title !~ a`,
+			placeholder: sqlgen.PlaceholderNamed,
+			wantSQL:     `title NOT LIKE :p1 ESCAPE '!'`,
+			wantArgs:    []any{"%a%"},
+		},
+		{
+			name:        "negation",
+			input:       `not title = a and not (age > 3 or category = b)`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `NOT (title = ?) AND NOT (age > ? OR category = ?)`,
+			wantArgs:    []any{"a", "3", "b"},
+		},
+		{
+			name:        "wildcards",
+			input:       `title = foo* and category != j?n_ and title =~ a*b`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `title LIKE ? ESCAPE '!' AND category NOT LIKE ? ESCAPE '!' AND title LIKE ? ESCAPE '!'`,
+			wantArgs:    []any{"foo%", "j_n!_", "%a%b%"},
+		},
+		{
+			name:        "escaped wildcards",
+			input:       `title = foo\*`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `title = ?`,
+			wantArgs:    []any{"foo*"},
+		},
+		{
+			name:        "in lists",
+			input:       `category in (a, b) and title not in (c)`,
+			placeholder: sqlgen.PlaceholderDollar,
+			wantSQL:     `category IN ($1, $2) AND title NOT IN ($3)`,
+			wantArgs:    []any{"a", "b", "c"},
+		},
+		{
+			name:        "ranges",
+			input:       `age between 18 and 30 or age = {1 to 5] or age = [3 to *] or age = {* to *}`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `age BETWEEN ? AND ? OR (age > ? AND age <= ?) OR age >= ? OR age IS NOT NULL`,
+			wantArgs:    []any{"18", "30", "1", "5", "3"},
+		},
+		{
+			name:        "quoted open bound",
+			input:       `title = ["*" TO m]`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `title BETWEEN ? AND ?`,
+			wantArgs:    []any{"*", "m"},
+		},
+		{
+			name:        "like wildcards are escaped",
+			input:       `title =~ "100%_off! [sale]"`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `title LIKE ? ESCAPE '!'`,
+			wantArgs:    []any{"%100!%!_off!! ![sale]%"},
+		},
+		{
+			name:        "values are never inlined",
+			input:       `title = "x' OR 1=1 --"`,
+			placeholder: sqlgen.PlaceholderQuestion,
+			wantSQL:     `title = ?`,
+			wantArgs:    []any{"x' OR 1=1 --"},
+		},
+		{
+			name:        "regular expressions are unsupported",
+			input:       `title =~ /a+/`,
+			wantErr:     true,
+			expectedErr: searchquerylexer.ErrUnsupportedOperator,
+		},
+		{
+			name:        "parse errors are returned",
+			input:       `title =`,
+			wantErr:     true,
+			expectedErr: searchquerylexer.ErrUnexpectedEndOfInput,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler, err := sqlgen.NewCompiler(config, sqlgen.Options{Placeholder: tt.placeholder})
+			assert.NoError(t, err)
+
+			gotSQL, gotArgs, err := compiler.Compile(tt.input)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantSQL, gotSQL)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			}
+		})
+	}
+}
+
+func TestCompileTokens(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	lexer, err := searchquerylexer.NewLexer(config)
+	assert.NoError(t, err)
+
+	compiler, err := sqlgen.NewCompiler(config, sqlgen.Options{Placeholder: sqlgen.PlaceholderDollar})
+	assert.NoError(t, err)
+
+	tokens, err := lexer.Tokenize(`title = a or title = b`)
+	assert.NoError(t, err)
+
+	gotSQL, gotArgs, err := compiler.CompileTokens(tokens)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `title = $1 OR title = $2`, gotSQL)
+	assert.Equal(t, []any{"a", "b"}, gotArgs)
+}
+
+func TestCompileUnknownField(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"title",
+		},
+	}
+
+	field := searchquerylexer.NewToken(searchquerylexer.TokenTypeFieldName, "1=1 OR title")
+
+	table := []struct {
+		name string
+		node searchquerylexer.Node
+	}{
+		{
+			name: "comparison",
+			node: &searchquerylexer.Comparison{
+				Field:      field,
+				Comparator: searchquerylexer.NewToken(searchquerylexer.TokenTypeComparator, "="),
+				Operator:   searchquerylexer.OperatorEqual,
+				Value:      searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+			},
+		},
+		{
+			name: "range",
+			node: &searchquerylexer.Range{
+				Field: field,
+				Lower: searchquerylexer.NewToken(searchquerylexer.TokenTypeValue, "a"),
+			},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs, err := sqlgen.CompileNode(tt.node, config, sqlgen.Options{})
+
+			assert.ErrorIs(t, err, searchquerylexer.ErrUnknownField)
+			assert.Empty(t, gotSQL)
+			assert.Nil(t, gotArgs)
+		})
+	}
+}
+
+func TestCompileColumnMapping(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"category",
+		},
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "title", Column: "documents.title_text"},
+			{Name: "age", Column: "EXTRACT(YEAR FROM AGE(birth_date))", Type: searchquerylexer.FieldTypeInt},
+		},
+	}
+
+	compiler, err := sqlgen.NewCompiler(config, sqlgen.Options{Placeholder: sqlgen.PlaceholderQuestion})
+	assert.NoError(t, err)
+
+	gotSQL, gotArgs, err := compiler.Compile(`title = a and age > 3 and category = b`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `documents.title_text = ? AND EXTRACT(YEAR FROM AGE(birth_date)) > ? AND category = ?`, gotSQL)
+	assert.Equal(t, []any{"a", int64(3), "b"}, gotArgs)
+}
+
+func TestCompileFreeText(t *testing.T) {
+	config := searchquerylexer.Config{
+		ComparatorConfig: searchquerylexer.ExtendedComparatorConfig,
+		ConnectiveConfig: searchquerylexer.ExtendedConnectiveConfig,
+		FieldNames: []string{
+			"body",
+		},
+		Fields: []searchquerylexer.FieldConfig{
+			{Name: "title", Column: "documents.title_text"},
+			{Name: "age", Type: searchquerylexer.FieldTypeInt},
+		},
+		DefaultFields: []string{"title", "body"},
+	}
+
+	compiler, err := sqlgen.NewCompiler(config, sqlgen.Options{Placeholder: sqlgen.PlaceholderDollar})
+	assert.NoError(t, err)
+
+	gotSQL, gotArgs, err := compiler.Compile(`"50%" and age > 3`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `(documents.title_text LIKE $1 ESCAPE '!' OR body LIKE $2 ESCAPE '!') AND age > $3`, gotSQL)
+	assert.Equal(t, []any{"%50!%%", "%50!%%", int64(3)}, gotArgs)
+
+	// Terms may start with a field name
+	gotSQL, gotArgs, err = compiler.Compile(`agenda or bodyguard`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `(documents.title_text LIKE $1 ESCAPE '!' OR body LIKE $2 ESCAPE '!') OR (documents.title_text LIKE $3 ESCAPE '!' OR body LIKE $4 ESCAPE '!')`, gotSQL)
+	assert.Equal(t, []any{"%agenda%", "%agenda%", "%bodyguard%", "%bodyguard%"}, gotArgs)
+
+	config.DefaultFields = nil
+
+	compiler, err = sqlgen.NewCompiler(config, sqlgen.Options{})
+	assert.NoError(t, err)
+
+	_, _, err = compiler.Compile(`invoice`)
+	assert.ErrorIs(t, err, searchquerylexer.ErrNoDefaultFields)
+}